@@ -0,0 +1,235 @@
+// Command classifier manages the category classifier: it imports hand-made
+// labels into the database, evaluates a model trained on them against the
+// keyword rules, and trains the model file the server loads.
+//
+//	classifier import labels.csv
+//	classifier eval [-holdout 0.2] [-min-confidence 0.7]
+//	classifier train [-o model.json]
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ahmetburakdinc/codefossils/internal/classify"
+	"github.com/ahmetburakdinc/codefossils/internal/config"
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+const usage = `usage: classifier <command> [flags]
+
+commands:
+  import FILE  store labels from a CSV file of source,id,categories rows;
+               categories are space-separated, primary first
+  eval         train on most labels and compare the model, with and without
+               the keyword fallback, to the keywords alone on the rest
+  train        train on all labels and write the model file
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+	labels := database.NewLabelStore(db)
+	categories := database.NewCategoryStore(db)
+	if err := categories.Seed(models.DefaultCategories()); err != nil {
+		log.Fatalf("Failed to seed categories: %v", err)
+	}
+	taxonomy, err := categories.Taxonomy()
+	if err != nil {
+		log.Fatalf("Failed to load categories: %v", err)
+	}
+
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "import":
+		err = runImport(labels, taxonomy, args)
+	case "eval":
+		err = runEval(labels, taxonomy, cfg, args)
+	case "train":
+		err = runTrain(labels, args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		db.Close()
+		log.Fatal(err)
+	}
+}
+
+func runImport(labels *database.LabelStore, taxonomy *models.Taxonomy, args []string) error {
+	if len(args) != 1 {
+		return errors.New("import takes one CSV file")
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	r.Comment = '#'
+	imported := 0
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading labels: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		source := strings.TrimSpace(rec[0])
+		id, err := strconv.ParseInt(strings.TrimSpace(rec[1]), 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return fmt.Errorf("line %d: invalid repo id %q", line, rec[1])
+		}
+		if !models.ValidSource(source) {
+			return fmt.Errorf("line %d: unknown source %q", line, rec[0])
+		}
+		categories := strings.Fields(rec[2])
+		if len(categories) == 0 {
+			return fmt.Errorf("line %d: no categories", line)
+		}
+		for _, c := range categories {
+			if !taxonomy.Has(c) {
+				return fmt.Errorf("line %d: unknown category %q", line, c)
+			}
+		}
+		if err := labels.Save(source, id, categories); err != nil {
+			return fmt.Errorf("line %d: saving labels: %w", line, err)
+		}
+		imported++
+	}
+	fmt.Printf("Imported labels for %d repos\n", imported)
+	return nil
+}
+
+func runEval(labels *database.LabelStore, taxonomy *models.Taxonomy, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	holdout := fs.Float64("holdout", 0.2, "fraction of labeled repos to evaluate on")
+	minConfidence := fs.Float64("min-confidence", cfg.ClassifierMinConfidence, "confidence below which keywords decide")
+	fs.Parse(args)
+
+	labeled, err := labels.Labeled()
+	if err != nil {
+		return err
+	}
+	train, test := classify.Split(labeled, *holdout)
+	if len(train) == 0 || len(test) == 0 {
+		return fmt.Errorf("%d labeled repos are too few to evaluate on", len(labeled))
+	}
+	model := classify.Train(train)
+	fmt.Printf("Trained on %d repos, evaluating on %d\n", len(train), len(test))
+
+	keywords := models.NewKeywordCategorizer(taxonomy)
+	for _, c := range []struct {
+		name        string
+		categorizer models.Categorizer
+	}{
+		{"keywords", keywords},
+		{"model", classify.NewCategorizer(model, 0, keywords)},
+		{fmt.Sprintf("model, keywords below %g", *minConfidence), classify.NewCategorizer(model, *minConfidence, keywords)},
+	} {
+		fmt.Printf("\n== %s\n\n", c.name)
+		printReport(classify.Evaluate(c.categorizer, test))
+	}
+	return nil
+}
+
+// printReport prints per-category precision and recall, then the confusion
+// matrix of primary categories with expected ones down the side.
+func printReport(r classify.Report) {
+	ids := r.CategoryIDs()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "category\tprecision\trecall\tf1\ttp\tfp\tfn\t")
+	var total classify.Metrics
+	for _, id := range ids {
+		m := r.Metrics[id]
+		if m == nil {
+			m = &classify.Metrics{}
+		}
+		total.TruePositives += m.TruePositives
+		total.FalsePositives += m.FalsePositives
+		total.FalseNegatives += m.FalseNegatives
+		printMetrics(w, id, *m)
+	}
+	printMetrics(w, "all", total)
+	w.Flush()
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "expected \\ assigned\t")
+	for _, id := range ids {
+		fmt.Fprintf(w, "%s\t", id)
+	}
+	fmt.Fprintln(w)
+	correct := 0
+	for _, expected := range ids {
+		fmt.Fprintf(w, "%s\t", expected)
+		for _, assigned := range ids {
+			n := r.Confusion[expected][assigned]
+			fmt.Fprintf(w, "%d\t", n)
+			if expected == assigned {
+				correct += n
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	fmt.Printf("\nPrimary category right for %d/%d repos\n", correct, r.Documents)
+}
+
+func printMetrics(w io.Writer, name string, m classify.Metrics) {
+	fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.2f\t%d\t%d\t%d\t\n",
+		name, m.Precision(), m.Recall(), m.F1(), m.TruePositives, m.FalsePositives, m.FalseNegatives)
+}
+
+func runTrain(labels *database.LabelStore, args []string) error {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	out := fs.String("o", "model.json", "model file to write")
+	fs.Parse(args)
+
+	labeled, err := labels.Labeled()
+	if err != nil {
+		return err
+	}
+	if len(labeled) == 0 {
+		return errors.New("no labeled repos to train on; import some first")
+	}
+	model := classify.Train(labeled)
+	if err := model.Save(*out); err != nil {
+		return err
+	}
+	fmt.Printf("Trained %s on %d repos in %d categories, wrote %s\n",
+		model.Version(), model.Documents, len(model.Categories), *out)
+	return nil
+}
@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/ahmetburakdinc/codefossils/internal/classify"
 	"github.com/ahmetburakdinc/codefossils/internal/config"
 	"github.com/ahmetburakdinc/codefossils/internal/database"
 	"github.com/ahmetburakdinc/codefossils/internal/github"
 	"github.com/ahmetburakdinc/codefossils/internal/handlers"
+	"github.com/ahmetburakdinc/codefossils/internal/jobs"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
 	"github.com/ahmetburakdinc/codefossils/internal/scheduler"
+	"github.com/ahmetburakdinc/codefossils/internal/scoring"
+	"github.com/ahmetburakdinc/codefossils/internal/sources"
 )
 
 func main() {
@@ -27,26 +39,213 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Cancelled on SIGINT/SIGTERM; stops refreshes, jobs and the server.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	scorer, err := scoring.New(cfg.Scorer, cfg.ScoreWeights)
+	if err != nil {
+		log.Fatalf("Failed to set up scoring: %v", err)
+	}
+	log.Printf("Scoring with %s", scorer.Version())
+	categoryStore := database.NewCategoryStore(db)
+	if err := categoryStore.Seed(models.DefaultCategories()); err != nil {
+		log.Fatalf("Failed to seed categories: %v", err)
+	}
+	taxonomy, err := categoryStore.Taxonomy()
+	if err != nil {
+		log.Fatalf("Failed to load categories: %v", err)
+	}
+	keywords := models.NewKeywordCategorizer(taxonomy)
+	var categorizer models.Categorizer = keywords
+	if cfg.ClassifierModel != "" {
+		model, err := classify.Load(cfg.ClassifierModel)
+		if err != nil {
+			log.Fatalf("Failed to load classifier: %v", err)
+		}
+		categorizer = classify.NewCategorizer(model, cfg.ClassifierMinConfidence, keywords)
+	}
+	log.Printf("Categorizing with %s", categorizer.Version())
+	evaluator := models.Evaluator{Scorer: scorer, Categorizer: categorizer}
+
 	store := database.NewRepoStore(db)
-	ghClient := github.NewClient(cfg.GitHubToken)
-	repoHandler := handlers.NewRepoHandler(store, ghClient)
+	queryStore := database.NewQueryStore(db)
+	if err := queryStore.SeedIfEmpty(github.DefaultQueries()); err != nil {
+		log.Fatalf("Failed to seed discovery queries: %v", err)
+	}
+	ghClient := newGitHubClient(cfg)
+	var srcs []sources.Source
+	for _, name := range cfg.Sources {
+		switch name {
+		case models.SourceGitHub:
+			srcs = append(srcs, ghClient)
+		case models.SourceGitLab:
+			srcs = append(srcs, sources.NewGitLab(cfg.GitLabURL, cfg.GitLabToken))
+		case models.SourceGitea:
+			gitea := sources.NewGitea(cfg.GiteaURL, cfg.GiteaToken)
+			// Repos used to be stored under a bare "gitea", whatever the host.
+			if n, err := store.RenameSource(models.SourceGitea, gitea.Name()); err != nil {
+				log.Fatalf("Failed to rename Gitea source: %v", err)
+			} else if n > 0 {
+				log.Printf("Moved %d Gitea repos to source %s", n, gitea.Name())
+			}
+			srcs = append(srcs, gitea)
+		case models.SourceBitbucket:
+			srcs = append(srcs, sources.NewBitbucket(cfg.BitbucketURL))
+		}
+	}
+	repoHandler := handlers.NewRepoHandler(store, queryStore, ghClient, srcs, evaluator, keywords)
+	repoHandler.SetLifetime(ctx, cfg.RefreshTimeout)
+	if cfg.DiscoveryMode == "crawl" {
+		repoHandler.EnableCrawl(database.NewCrawlStore(db), cfg.CrawlPages)
+	}
+	queryHandler := handlers.NewQueryHandler(queryStore)
 
 	// Start background scheduler
 	sched := scheduler.New(repoHandler, store, cfg.RefreshInterval)
-	sched.Start()
+	revalidator := jobs.NewRevalidator(store, ghClient, evaluator, cfg.RevalidateBatch, cfg.RevalidateAfter)
+	sched.AddJob("revalidate", cfg.RevalidateEvery, revalidator.Run)
+	readmes := jobs.NewReadmeBackfill(store, ghClient, evaluator, cfg.EnrichBatch)
+	sched.AddJob("readmes", cfg.EnrichEvery, readmes.Run)
+	stacks := jobs.NewStackBackfill(store, ghClient, evaluator, cfg.EnrichBatch)
+	sched.AddJob("stacks", cfg.EnrichEvery, stacks.Run)
+	health := jobs.NewHealthEnricher(store, ghClient, evaluator, cfg.EnrichBatch)
+	sched.AddJob("health", cfg.EnrichEvery, health.Run)
+	owners := jobs.NewOwnerEnricher(database.NewOwnerStore(db), ghClient, cfg.EnrichBatch)
+	sched.AddJob("owners", cfg.EnrichEvery, owners.Run)
+	activity := jobs.NewActivityEnricher(store, ghClient, cfg.EnrichBatch)
+	sched.AddJob("activity", cfg.EnrichEvery, activity.Run)
+	clusterStore := database.NewClusterStore(db)
+	clusterer := jobs.NewClusterer(clusterStore, cfg.ClusterThreshold)
+	sched.AddJob("clusters", cfg.ClusterEvery, clusterer.Run)
+	// Repos evaluated under another scorer or categorizer version, or before
+	// Evaluate derived everything it does now, are re-evaluated once at
+	// startup; everything ingested from here on is evaluated with evaluator.
+	// Scores older than ReevaluateAfter are recomputed as they come due.
+	reevaluator := jobs.NewReevaluator(store, evaluator, cfg.ReevaluateAfter)
+	sched.AddJob("reevaluate", cfg.EnrichEvery, reevaluator.Run)
+	sched.Start(ctx)
+	go reevaluator.Run(ctx)
+	categoryHandler := handlers.NewCategoryHandler(categoryStore, keywords, func() { go reevaluator.Run(ctx) })
+	clusterHandler := handlers.NewClusterHandler(clusterStore)
+	// Clusters are regrouped once at startup, then every ClusterEvery.
+	go clusterer.Run(ctx)
 
 	// Routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/repos", corsMiddleware(repoHandler.ListRepos))
 	mux.HandleFunc("/api/repos/refresh", corsMiddleware(repoHandler.RefreshRepos))
+	// Not method-scoped: GET /api/repos/{id} would conflict with the refresh route.
+	mux.HandleFunc("/api/repos/{id}", corsMiddleware(repoHandler.GetRepo))
+	mux.HandleFunc("GET /api/repos/{id}/readme", corsMiddleware(repoHandler.Readme))
 	mux.HandleFunc("/api/stats", corsMiddleware(repoHandler.Stats))
+	mux.HandleFunc("GET /api/categories", corsMiddleware(categoryHandler.List))
+	mux.HandleFunc("GET /api/clusters/{id}", corsMiddleware(clusterHandler.Get))
+
+	// Admin API
+	admin := adminMiddleware(cfg.AdminToken)
+	mux.HandleFunc("GET /api/admin/queries", admin(queryHandler.List))
+	mux.HandleFunc("POST /api/admin/queries", admin(queryHandler.Create))
+	mux.HandleFunc("PUT /api/admin/queries/{id}", admin(queryHandler.Update))
+	mux.HandleFunc("DELETE /api/admin/queries/{id}", admin(queryHandler.Delete))
+	mux.HandleFunc("GET /api/admin/queries/{id}/runs", admin(queryHandler.Runs))
+	mux.HandleFunc("POST /api/admin/categories", admin(categoryHandler.Create))
+	mux.HandleFunc("PUT /api/admin/categories/{id...}", admin(categoryHandler.Update))
+	mux.HandleFunc("DELETE /api/admin/categories/{id...}", admin(categoryHandler.Delete))
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down server: %v", err)
+		}
+	}()
 
 	log.Printf("Server starting on :%s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
 
+// newGitHubClient builds the GitHub client from the credentials, API style,
+// cache and fixture settings in cfg.
+func newGitHubClient(cfg *config.Config) *github.Client {
+	endpoints := github.ResolveEndpoints(cfg.GitHubAPIURL, cfg.GitHubGraphQLURL, cfg.GitHubUploadURL)
+	ghOpts := []github.Option{github.WithEndpoints(endpoints), github.WithConcurrency(cfg.GitHubConcurrency)}
+	if endpoints.Enterprise() {
+		log.Printf("Using GitHub Enterprise Server at %s", endpoints.API)
+	}
+	switch cfg.GitHubAPIVersion {
+	case "":
+	case "none":
+		ghOpts = append(ghOpts, github.WithAPIVersion(""))
+	default:
+		ghOpts = append(ghOpts, github.WithAPIVersion(cfg.GitHubAPIVersion))
+	}
+	if cfg.GitHubAPI == "graphql" {
+		ghOpts = append(ghOpts, github.WithGraphQL())
+	}
+	if cfg.GitHubCacheDir != "" {
+		cache, err := github.NewFileCache(cfg.GitHubCacheDir, cfg.GitHubCacheMaxBytes)
+		if err != nil {
+			log.Fatalf("Failed to open GitHub response cache: %v", err)
+		}
+		ghOpts = append(ghOpts, github.WithCache(cache))
+	}
+
+	if cfg.GitHubFixtures != "" {
+		date, err := github.FixtureDate(cfg.GitHubFixturesDir, cfg.GitHubFixtures == "record")
+		if err != nil {
+			log.Fatalf("Failed to load GitHub fixtures: %v", err)
+		}
+		ghOpts = append(ghOpts, github.WithDate(date), github.WithSeed(cfg.GitHubSeed))
+	}
+	switch cfg.GitHubFixtures {
+	case "record":
+		recorder, err := github.NewRecordingTransport(cfg.GitHubFixturesDir, nil)
+		if err != nil {
+			log.Fatalf("Failed to set up GitHub recording: %v", err)
+		}
+		log.Printf("Recording GitHub traffic to %s", cfg.GitHubFixturesDir)
+		ghOpts = append(ghOpts, github.WithTransport(recorder))
+	case "replay":
+		baseURL, err := github.StartReplayServer(cfg.GitHubFixturesDir)
+		if err != nil {
+			log.Fatalf("Failed to start GitHub replay server: %v", err)
+		}
+		log.Printf("Replaying GitHub traffic from %s via %s", cfg.GitHubFixturesDir, baseURL)
+		ghOpts = append(ghOpts, github.WithBaseURL(baseURL))
+		// The fake server ignores credentials; a placeholder keeps GraphQL
+		// enabled without needing a real token or network access.
+		return github.NewClient([]github.Credential{github.StaticToken("replay")}, ghOpts...)
+	}
+
+	var creds []github.Credential
+	for _, token := range cfg.GitHubTokens {
+		creds = append(creds, github.StaticToken(token))
+	}
+	if cfg.GitHubAppID != "" {
+		key, err := os.ReadFile(cfg.GitHubAppKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to read GitHub App private key: %v", err)
+		}
+		appURL := cfg.GitHubAppAPIURL
+		if appURL == "" {
+			appURL = endpoints.API
+		}
+		app, err := github.NewAppCredential(cfg.GitHubAppID, cfg.GitHubAppInstallationID, key, appURL)
+		if err != nil {
+			log.Fatalf("Failed to set up GitHub App auth: %v", err)
+		}
+		creds = append(creds, app)
+	}
+	log.Printf("Using %d GitHub credential(s)", len(creds))
+	return github.NewClient(creds, ghOpts...)
+}
+
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -61,3 +260,23 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r)
 	}
 }
+
+// adminMiddleware guards admin routes with a static bearer token. Without a
+// configured token the admin API is disabled entirely.
+func adminMiddleware(token string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.Error(w, `{"error":"admin API disabled"}`, http.StatusNotFound)
+				return
+			}
+			got := []byte(r.Header.Get("Authorization"))
+			want := []byte("Bearer " + token)
+			if subtle.ConstantTimeCompare(got, want) != 1 {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package classify
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// memberConfidence is the probability above which a repo is labeled with a
+// category besides its most likely one.
+const memberConfidence = 0.5
+
+// Categorizer categorizes repos with a trained model, leaving the repos it
+// isn't sure about to the keyword rules.
+type Categorizer struct {
+	model         *Model
+	minConfidence float64
+	fallback      *models.KeywordCategorizer
+}
+
+// NewCategorizer returns a Categorizer that trusts model when its most likely
+// category is at least minConfidence likely, and asks fallback otherwise.
+// Categories the model learned that are no longer in fallback's taxonomy,
+// because they were since deleted or renamed, are never assigned.
+func NewCategorizer(model *Model, minConfidence float64, fallback *models.KeywordCategorizer) *Categorizer {
+	return &Categorizer{model: model, minConfidence: minConfidence, fallback: fallback}
+}
+
+// Version covers the model, the confidence it needs and the fallback, since
+// a change to any of them changes which repos land where.
+func (c *Categorizer) Version() string {
+	return fmt.Sprintf("bayes.%s@%g+%s", c.model.Version(), c.minConfidence, c.fallback.Version())
+}
+
+func (c *Categorizer) Categorize(r models.Repo) []models.CategoryLabel {
+	taxonomy := c.fallback.Taxonomy()
+	var predicted []models.CategoryLabel
+	for _, l := range c.model.Predict(r) {
+		if taxonomy.Has(l.ID) {
+			predicted = append(predicted, l)
+		}
+	}
+	if len(predicted) == 0 || predicted[0].Confidence < c.minConfidence {
+		return c.fallback.Categorize(r)
+	}
+
+	labels := []models.CategoryLabel{roundConfidence(predicted[0])}
+	for _, l := range predicted[1:] {
+		// A repo that fits somewhere isn't also one that fits nowhere.
+		if l.Confidence >= memberConfidence && l.ID != models.CategoryOther {
+			labels = append(labels, roundConfidence(l))
+		}
+	}
+	if labels[0].ID == models.CategoryOther && len(labels) > 1 {
+		labels = labels[1:]
+	}
+	return labels
+}
+
+func roundConfidence(l models.CategoryLabel) models.CategoryLabel {
+	l.Confidence = math.Round(l.Confidence*100) / 100
+	return l
+}
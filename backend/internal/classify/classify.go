@@ -0,0 +1,245 @@
+// Package classify categorizes repos with a naive Bayes model trained on
+// hand-labeled repos. Each category is a yes/no question of its own, so a
+// repo can belong to several, as with the keyword rules it complements.
+package classify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// modelFormat is the model file format written by Save. Load rejects others.
+const modelFormat = 1
+
+// smoothing is the Laplace smoothing added to every token count, so a token
+// never seen with a category doesn't rule it out.
+const smoothing = 1.0
+
+// Model holds token counts per category, learned from labeled repos.
+type Model struct {
+	Format     int                        `json:"format"`
+	TrainedAt  time.Time                  `json:"trained_at"`
+	Documents  int                        `json:"documents"`
+	Tokens     map[string]int             `json:"tokens"` // over all documents
+	Categories map[string]*CategoryCounts `json:"categories"`
+
+	total   int    // sum of Tokens
+	version string // see Version
+}
+
+// CategoryCounts are the token counts of the documents labeled with one
+// category.
+type CategoryCounts struct {
+	Documents int            `json:"documents"`
+	Tokens    map[string]int `json:"tokens"`
+
+	total int
+}
+
+// Train counts the tokens of every labeled repo under each of its categories.
+func Train(labeled []models.LabeledRepo) *Model {
+	m := &Model{
+		Format:     modelFormat,
+		TrainedAt:  time.Now().UTC().Truncate(time.Second),
+		Tokens:     make(map[string]int),
+		Categories: make(map[string]*CategoryCounts),
+	}
+	for _, l := range labeled {
+		tokens := Tokenize(l.Repo)
+		m.Documents++
+		for _, t := range tokens {
+			m.Tokens[t]++
+		}
+		for _, cat := range l.Categories {
+			c := m.Categories[cat]
+			if c == nil {
+				c = &CategoryCounts{Tokens: make(map[string]int)}
+				m.Categories[cat] = c
+			}
+			c.Documents++
+			for _, t := range tokens {
+				c.Tokens[t]++
+			}
+		}
+	}
+	m.prepare()
+	return m
+}
+
+// Load reads a model file written by Save.
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading model: %w", err)
+	}
+	var m Model
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decoding model %s: %w", path, err)
+	}
+	if m.Format != modelFormat {
+		return nil, fmt.Errorf("model %s has format %d, want %d", path, m.Format, modelFormat)
+	}
+	if m.Documents == 0 || len(m.Categories) == 0 {
+		return nil, fmt.Errorf("model %s was trained on nothing", path)
+	}
+	m.prepare()
+	return &m, nil
+}
+
+// Save writes the model to path.
+func (m *Model) Save(path string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding model: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing model: %w", err)
+	}
+	return nil
+}
+
+// prepare computes the totals and version of freshly trained or loaded counts.
+func (m *Model) prepare() {
+	m.total = 0
+	for _, n := range m.Tokens {
+		m.total += n
+	}
+	for _, c := range m.Categories {
+		c.total = 0
+		for _, n := range c.Tokens {
+			c.total += n
+		}
+	}
+	// Map keys are encoded sorted, so equal counts hash alike.
+	data, _ := json.Marshal(struct {
+		Tokens     map[string]int             `json:"tokens"`
+		Categories map[string]*CategoryCounts `json:"categories"`
+	}{m.Tokens, m.Categories})
+	sum := sha256.Sum256(data)
+	m.version = hex.EncodeToString(sum[:4])
+}
+
+// Version identifies the model's counts.
+func (m *Model) Version() string { return m.version }
+
+// CategoryIDs returns the categories the model knows, sorted.
+func (m *Model) CategoryIDs() []string {
+	ids := make([]string, 0, len(m.Categories))
+	for id := range m.Categories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Predict returns the probability of every category the model knows, most
+// likely first. Tokens the model has never seen are ignored. Naive Bayes
+// treats every token as independent evidence and grows certain far faster
+// than it should on long texts; the token evidence is divided by the square
+// root of the token count to temper that.
+func (m *Model) Predict(r models.Repo) []models.CategoryLabel {
+	counts := make(map[string]int)
+	n := 0
+	for _, t := range Tokenize(r) {
+		if m.Tokens[t] > 0 {
+			counts[t]++
+			n++
+		}
+	}
+	vocabulary := float64(len(m.Tokens))
+	temper := math.Sqrt(max(1, float64(n)))
+
+	labels := make([]models.CategoryLabel, 0, len(m.Categories))
+	for _, id := range m.CategoryIDs() {
+		c := m.Categories[id]
+		restTotal := m.total - c.total
+
+		// Log odds of the category against the rest, with smoothed priors.
+		prior := math.Log(float64(c.Documents)+1) - math.Log(float64(m.Documents-c.Documents)+1)
+		evidence := 0.0
+		for t, k := range counts {
+			in := (float64(c.Tokens[t]) + smoothing) / (float64(c.total) + smoothing*vocabulary)
+			out := (float64(m.Tokens[t]-c.Tokens[t]) + smoothing) / (float64(restTotal) + smoothing*vocabulary)
+			evidence += float64(k) * math.Log(in/out)
+		}
+		p := 1 / (1 + math.Exp(-(prior + evidence/temper)))
+		labels = append(labels, models.CategoryLabel{ID: id, Confidence: p})
+	}
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].Confidence > labels[j].Confidence })
+	return labels
+}
+
+// stopwords are too common in repo descriptions to say anything.
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "of": true, "to": true,
+	"in": true, "on": true, "an": true, "is": true, "it": true, "this": true,
+	"that": true, "your": true, "you": true, "from": true, "by": true, "or": true,
+	"be": true, "are": true, "as": true, "at": true, "can": true, "using": true,
+	"use": true, "my": true, "we": true, "all": true, "not": true, "based": true,
+}
+
+// Tokenize returns the features the model looks at: words and word pairs
+// from the repo's name, description, README excerpt and topics, and its
+// language and stack entries as features of their own.
+func Tokenize(r models.Repo) []string {
+	var tokens []string
+	for _, text := range []string{splitCamel(r.Name), r.Description, r.ReadmeExcerpt} {
+		tokens = appendWords(tokens, text)
+	}
+	for _, topic := range r.Topics {
+		tokens = append(tokens, "topic:"+strings.ToLower(topic))
+		tokens = appendWords(tokens, topic)
+	}
+	if r.Language != "" {
+		tokens = append(tokens, "lang:"+strings.ToLower(r.Language))
+	}
+	for _, s := range r.Stack {
+		tokens = append(tokens, "stack:"+s)
+	}
+	return tokens
+}
+
+// appendWords appends the words of text, and each pair of adjacent words,
+// lowercased and without stopwords or numbers.
+func appendWords(tokens []string, text string) []string {
+	prev := ""
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) < 2 || stopwords[w] || strings.TrimFunc(w, unicode.IsDigit) == "" {
+			prev = ""
+			continue
+		}
+		tokens = append(tokens, w)
+		if prev != "" {
+			tokens = append(tokens, prev+"_"+w)
+		}
+		prev = w
+	}
+	return tokens
+}
+
+// splitCamel separates the words of a camel-cased name: "ImageNetLoader"
+// becomes "Image Net Loader".
+func splitCamel(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		repo models.Repo
+		want []string
+	}{
+		{
+			models.Repo{Name: "ImageNetLoader"},
+			[]string{"image", "net", "image_net", "loader", "net_loader"},
+		},
+		{
+			// Stopwords and numbers break word pairs.
+			models.Repo{Description: "A chat app for the 2048 game"},
+			[]string{"chat", "app", "chat_app", "game"},
+		},
+		{
+			models.Repo{Topics: []string{"Machine-Learning"}, Language: "Python", Stack: []string{"pytorch"}},
+			[]string{"topic:machine-learning", "machine", "learning", "machine_learning", "lang:python", "stack:pytorch"},
+		},
+	}
+	for i, tt := range tests {
+		if got := Tokenize(tt.repo); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: Tokenize = %q, want %q", i, got, tt.want)
+		}
+	}
+}
+
+// trainingSet is a few hand-labeled repos per category, enough for the
+// model to tell them apart.
+func trainingSet() []models.LabeledRepo {
+	label := func(desc, lang string, categories ...string) models.LabeledRepo {
+		return models.LabeledRepo{Repo: models.Repo{Description: desc, Language: lang}, Categories: categories}
+	}
+	return []models.LabeledRepo{
+		label("Android app to track workouts", "Kotlin", "mobile"),
+		label("iOS app for reading recipes offline", "Swift", "mobile"),
+		label("Mobile app that scans receipts", "Kotlin", "mobile"),
+		label("Platformer game with pixel art levels", "C#", "game"),
+		label("Multiplayer card game in the browser", "JavaScript", "game"),
+		label("Roguelike dungeon game", "Rust", "game"),
+		label("Neural network that classifies images", "Python", "ai"),
+		label("Train a neural model to generate text", "Python", "ai"),
+		label("Image classification with neural nets on a mobile app", "Python", "ai", "mobile"),
+	}
+}
+
+func TestTrain(t *testing.T) {
+	m := Train(trainingSet())
+	if m.Documents != 9 {
+		t.Errorf("trained on %d documents, want 9", m.Documents)
+	}
+	if got, want := m.CategoryIDs(), []string{"ai", "game", "mobile"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("categories %q, want %q", got, want)
+	}
+	if n := m.Categories["mobile"].Documents; n != 4 {
+		t.Errorf("mobile has %d documents, want 4", n)
+	}
+	if n := m.Categories["ai"].Tokens["neural"]; n != 3 {
+		t.Errorf("neural counted %d times under ai, want 3", n)
+	}
+	if again := Train(trainingSet()); again.Version() != m.Version() {
+		t.Errorf("same labels gave versions %s and %s", m.Version(), again.Version())
+	}
+}
+
+func TestPredict(t *testing.T) {
+	m := Train(trainingSet())
+	tests := []struct {
+		repo models.Repo
+		want string
+	}{
+		{models.Repo{Description: "Kotlin app for Android", Language: "Kotlin"}, "mobile"},
+		{models.Repo{Description: "A dungeon card game"}, "game"},
+		{models.Repo{Description: "Neural text generation", Language: "Python"}, "ai"},
+	}
+	for _, tt := range tests {
+		labels := m.Predict(tt.repo)
+		if len(labels) != 3 || labels[0].ID != tt.want || labels[0].Confidence <= 0.5 {
+			t.Errorf("Predict(%q) = %v, want %s first and likely", tt.repo.Description, labels, tt.want)
+		}
+		for i := 1; i < len(labels); i++ {
+			if labels[i].Confidence > labels[i-1].Confidence {
+				t.Errorf("Predict(%q) = %v, not most likely first", tt.repo.Description, labels)
+			}
+		}
+	}
+}
+
+// TestCategorizerDropsUnknownCategories checks that categories the model
+// learned but the taxonomy no longer has are never assigned, and that a
+// repo left with nothing goes to the keywords.
+func TestCategorizerDropsUnknownCategories(t *testing.T) {
+	var categories []models.Category
+	for _, c := range models.DefaultCategories() {
+		if c.ID != "game" && c.Parent != "game" {
+			categories = append(categories, c)
+		}
+	}
+	taxonomy, err := models.NewTaxonomy(categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keywords := models.NewKeywordCategorizer(taxonomy)
+	c := NewCategorizer(Train(trainingSet()), 0.5, keywords)
+
+	repo := models.Repo{Description: "Roguelike dungeon card game"}
+	got := c.Categorize(repo)
+	if want := keywords.Categorize(repo); !reflect.DeepEqual(got, want) {
+		t.Errorf("Categorize = %v, want the keywords' %v", got, want)
+	}
+	for _, l := range got {
+		if l.ID == "game" {
+			t.Errorf("Categorize = %v, assigned deleted category game", got)
+		}
+	}
+
+	if got := c.Categorize(models.Repo{Description: "Neural text generation", Language: "Python"}); len(got) == 0 || got[0].ID != "ai" {
+		t.Errorf("Categorize = %v, want ai from the model", got)
+	}
+}
@@ -0,0 +1,138 @@
+package classify
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// Split divides labeled repos into a training and a test set, putting about
+// the holdout fraction of them in the test set. Repos are assigned by a hash
+// of their ID, so the split is the same on every run and a repo stays on its
+// side as more labels are added.
+func Split(labeled []models.LabeledRepo, holdout float64) (train, test []models.LabeledRepo) {
+	for _, l := range labeled {
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%s/%d", l.Repo.SourceName(), l.Repo.ID)
+		if float64(h.Sum32()%1000) < holdout*1000 {
+			test = append(test, l)
+		} else {
+			train = append(train, l)
+		}
+	}
+	return train, test
+}
+
+// Metrics counts a categorizer's hits and misses on one category.
+type Metrics struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+}
+
+// Precision is the share of repos labeled with the category that have it.
+func (m Metrics) Precision() float64 {
+	return ratio(m.TruePositives, m.TruePositives+m.FalsePositives)
+}
+
+// Recall is the share of repos with the category that were labeled with it.
+func (m Metrics) Recall() float64 {
+	return ratio(m.TruePositives, m.TruePositives+m.FalseNegatives)
+}
+
+// F1 is the harmonic mean of precision and recall.
+func (m Metrics) F1() float64 {
+	p, r := m.Precision(), m.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// Report is how well a categorizer's labels match hand-assigned ones.
+type Report struct {
+	Documents int
+	// Metrics compares every label assigned against every label expected.
+	Metrics map[string]*Metrics
+	// Confusion counts primary categories: Confusion[expected][assigned].
+	Confusion map[string]map[string]int
+}
+
+// Evaluate categorizes the labeled repos with c and compares the result to
+// their labels.
+func Evaluate(c models.Categorizer, labeled []models.LabeledRepo) Report {
+	report := Report{
+		Metrics:   make(map[string]*Metrics),
+		Confusion: make(map[string]map[string]int),
+	}
+	metrics := func(id string) *Metrics {
+		if report.Metrics[id] == nil {
+			report.Metrics[id] = &Metrics{}
+		}
+		return report.Metrics[id]
+	}
+
+	for _, l := range labeled {
+		if len(l.Categories) == 0 {
+			continue
+		}
+		report.Documents++
+		assigned := c.Categorize(l.Repo)
+
+		expected := make(map[string]bool, len(l.Categories))
+		for _, id := range l.Categories {
+			expected[id] = true
+		}
+		got := make(map[string]bool, len(assigned))
+		for _, a := range assigned {
+			got[a.ID] = true
+			if expected[a.ID] {
+				metrics(a.ID).TruePositives++
+			} else {
+				metrics(a.ID).FalsePositives++
+			}
+		}
+		for id := range expected {
+			if !got[id] {
+				metrics(id).FalseNegatives++
+			}
+		}
+
+		row := report.Confusion[l.Categories[0]]
+		if row == nil {
+			row = make(map[string]int)
+			report.Confusion[l.Categories[0]] = row
+		}
+		row[assigned[0].ID]++
+	}
+	return report
+}
+
+// CategoryIDs returns every category that occurs in the report, sorted.
+func (r Report) CategoryIDs() []string {
+	seen := make(map[string]bool)
+	for id := range r.Metrics {
+		seen[id] = true
+	}
+	for expected, row := range r.Confusion {
+		seen[expected] = true
+		for assigned := range row {
+			seen[assigned] = true
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
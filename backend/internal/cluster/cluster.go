@@ -0,0 +1,164 @@
+// Package cluster groups near-duplicate repos, such as the many todo apps
+// or socket.io chat apps, into idea clusters. Repos are compared by the
+// words of their name, description, topics and README excerpt, using MinHash
+// signatures to find likely pairs without comparing every repo to every
+// other.
+package cluster
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+const (
+	// readmeWords is how much of the README excerpt counts. Past the first
+	// paragraph READMEs drift into setup steps every project shares.
+	readmeWords = 40
+	// minFeatures is how many distinct words a repo needs to be clustered;
+	// with fewer, "my-app" would match every other "app".
+	minFeatures = 3
+	// maxTerms is how many shared words sum up a cluster.
+	maxTerms = 5
+)
+
+// stopwords say nothing about a repo's idea.
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "is": true,
+	"an": true, "to": true, "of": true, "in": true, "on": true, "it": true,
+	"that": true, "your": true, "you": true, "from": true, "by": true, "or": true,
+	"be": true, "are": true, "as": true, "at": true, "can": true, "using": true,
+	"use": true, "my": true, "we": true, "all": true, "not": true, "based": true,
+	"simple": true, "project": true, "repository": true, "repo": true, "built": true,
+	"made": true, "written": true, "small": true, "basic": true, "new": true,
+	"just": true, "some": true, "another": true, "first": true, "how": true,
+	"readme": true, "md": true, "www": true, "http": true, "https": true,
+}
+
+// Features returns the distinct words of the repo's name, description,
+// topics and the start of its README excerpt, lowercased and without
+// stopwords or numbers.
+func Features(r models.Repo) []string {
+	seen := make(map[string]bool)
+	var features []string
+	add := func(text string, limit int) {
+		n := 0
+		for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if limit > 0 && n == limit {
+				return
+			}
+			n++
+			if len(w) < 2 || stopwords[w] || strings.TrimFunc(w, unicode.IsDigit) == "" || seen[w] {
+				continue
+			}
+			seen[w] = true
+			features = append(features, w)
+		}
+	}
+	add(splitCamel(r.Name), 0)
+	add(r.Description, 0)
+	add(strings.Join(r.Topics, " "), 0)
+	add(r.ReadmeExcerpt, readmeWords)
+	return features
+}
+
+// splitCamel separates the words of a camel-cased name: "TodoApp" becomes
+// "Todo App".
+func splitCamel(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Group clusters repos, which must come best first, and returns each cluster
+// of two or more as indexes into repos, best first. Each repo joins the most
+// similar cluster whose first repo, its representative, it is at least
+// threshold similar to; comparing against representatives only keeps
+// chains of slightly different repos from merging unrelated ideas.
+func Group(repos []models.Repo, threshold float64) [][]int {
+	var leaders []int
+	var signatures []Signature
+	members := make(map[int][]int)
+	buckets := make(map[uint64][]int) // band key -> indexes into leaders
+
+	for i, r := range repos {
+		features := Features(r)
+		if len(features) < minFeatures {
+			continue
+		}
+		sig := Sign(features)
+		keys := sig.bands()
+
+		best, bestSim := -1, 0.0
+		tried := make(map[int]bool)
+		for _, key := range keys {
+			for _, l := range buckets[key] {
+				if tried[l] {
+					continue
+				}
+				tried[l] = true
+				// Ties go to the better leader, which came first.
+				sim := sig.Similarity(&signatures[l])
+				if sim >= threshold && (best < 0 || sim > bestSim || sim == bestSim && l < best) {
+					best, bestSim = l, sim
+				}
+			}
+		}
+		if best >= 0 {
+			members[best] = append(members[best], i)
+			continue
+		}
+
+		l := len(leaders)
+		leaders = append(leaders, i)
+		signatures = append(signatures, sig)
+		for _, key := range keys {
+			buckets[key] = append(buckets[key], l)
+		}
+	}
+
+	var clusters [][]int
+	for l, leader := range leaders {
+		if len(members[l]) > 0 {
+			clusters = append(clusters, append([]int{leader}, members[l]...))
+		}
+	}
+	return clusters
+}
+
+// Terms returns the words at least half of the repos share, most common
+// first, up to maxTerms of them.
+func Terms(repos []models.Repo) []string {
+	counts := make(map[string]int)
+	for _, r := range repos {
+		for _, f := range Features(r) {
+			counts[f]++
+		}
+	}
+	var terms []string
+	for f, n := range counts {
+		if 2*n >= len(repos) {
+			terms = append(terms, f)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if counts[terms[i]] != counts[terms[j]] {
+			return counts[terms[i]] > counts[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+	return terms
+}
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+func TestSimilarity(t *testing.T) {
+	words := func(from, to int) []string {
+		var w []string
+		for i := from; i < to; i++ {
+			w = append(w, fmt.Sprintf("word%d", i))
+		}
+		return w
+	}
+	tests := []struct {
+		a, b []string
+		want float64 // Jaccard similarity of a and b
+	}{
+		{words(0, 20), words(0, 20), 1},
+		{words(0, 20), append(words(0, 20), words(0, 20)...), 1},
+		{words(0, 20), words(20, 40), 0},
+		{words(0, 30), words(10, 40), 0.5},
+		{words(0, 40), words(10, 40), 0.75},
+	}
+	for _, tt := range tests {
+		a, b := Sign(tt.a), Sign(tt.b)
+		if got := a.Similarity(&b); math.Abs(got-tt.want) > 0.15 {
+			t.Errorf("similarity of %d and %d words = %.2f, want about %.2f", len(tt.a), len(tt.b), got, tt.want)
+		}
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	r := models.Repo{
+		Name:          "TodoApp",
+		Description:   "A simple todo app for the 2020 hackathon",
+		Topics:        []string{"react", "todo"},
+		ReadmeExcerpt: "Todo app. Install with npm",
+	}
+	want := []string{"todo", "app", "hackathon", "react", "install", "npm"}
+	if got := Features(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("Features = %q, want %q", got, want)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	repo := func(name, desc string) models.Repo {
+		return models.Repo{Name: name, Description: desc}
+	}
+	todoA := repo("todo-list", "React todo list app with local storage and dark mode")
+	todoB := repo("react-todo-list", "Todo list app in React with local storage and dark mode")
+	todoC := repo("TodoList", "Todo list app using React, local storage, dark mode")
+	chatA := repo("socket-chat", "Realtime chat room with socket.io and express")
+	chatB := repo("chat-room", "Realtime socket.io chat room on express")
+	weather := repo("weather-cli", "Command line weather forecast from OpenWeatherMap")
+	chess := repo("chess-engine", "Bitboard chess engine with alpha-beta search")
+	tiny := repo("app", "my app")
+
+	tests := []struct {
+		name  string
+		repos []models.Repo
+		want  [][]int
+	}{
+		{"near-identical todo apps", []models.Repo{todoA, todoB, todoC}, [][]int{{0, 1, 2}}},
+		{"unrelated repos", []models.Repo{weather, chess, todoA, chatA}, nil},
+		{"two ideas", []models.Repo{todoA, chatA, weather, todoB, chatB, chess, todoC}, [][]int{{0, 3, 6}, {1, 4}}},
+		// The best repo leads its cluster, whichever one it is.
+		{"leader is best", []models.Repo{todoC, todoB, todoA}, [][]int{{0, 1, 2}}},
+		{"clusters best first", []models.Repo{chatB, todoB, chatA, todoA}, [][]int{{0, 2}, {1, 3}}},
+		{"too few words", []models.Repo{tiny, tiny}, nil},
+	}
+	for _, tt := range tests {
+		if got := Group(tt.repos, 0.5); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Group = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestGroupJoinsMostSimilarLeader checks that a repo similar enough to two
+// representatives joins the closer one, not the better one.
+func TestGroupJoinsMostSimilarLeader(t *testing.T) {
+	const threshold = 0.35
+	better := models.Repo{Name: "kanban", Description: "board drag drop cards firebase auth realtime vue vuex"}
+	closer := models.Repo{Name: "kanban", Description: "board drag drop cards electron desktop offline sqlite tray shortcuts"}
+	repo := models.Repo{Name: "kanban", Description: "board drag drop cards firebase auth realtime electron desktop offline sqlite tray shortcuts"}
+
+	sig := func(r models.Repo) Signature { return Sign(Features(r)) }
+	b, c, r := sig(better), sig(closer), sig(repo)
+	if !(b.Similarity(&c) < threshold && threshold <= r.Similarity(&b) && r.Similarity(&b) < r.Similarity(&c)) {
+		t.Fatalf("similarities %.2f, %.2f, %.2f don't set up the test", b.Similarity(&c), r.Similarity(&b), r.Similarity(&c))
+	}
+	if got := Group([]models.Repo{better, closer, repo}, threshold); !reflect.DeepEqual(got, [][]int{{1, 2}}) {
+		t.Errorf("Group = %v, want [[1 2]]", got)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	repos := []models.Repo{
+		{Name: "todo-list", Description: "React todo list with hooks"},
+		{Name: "react-todo", Description: "Todo list in React"},
+		{Name: "vue-todo", Description: "Todo app in Vue"},
+	}
+	if got, want := Terms(repos), []string{"todo", "list", "react"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package cluster
+
+import "hash/fnv"
+
+// Signatures have numHashes minimums, split into bands of bandRows for
+// locality-sensitive hashing. With 32 bands of 4 rows, repos sharing half
+// their words land in a common bucket 87% of the time, and ones sharing 60%
+// of them 99% of the time.
+const (
+	numHashes = 128
+	bandRows  = 4
+	numBands  = numHashes / bandRows
+)
+
+// Signature is a MinHash signature of a set of features: for each of
+// numHashes hash functions, the smallest hash of any feature. The fraction
+// of positions where two signatures agree estimates the Jaccard similarity
+// of their sets.
+type Signature [numHashes]uint32
+
+// Sign computes the signature of features. Duplicates don't matter.
+func Sign(features []string) Signature {
+	var sig Signature
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+	for _, f := range features {
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		// Derive every hash function from two halves of one hash
+		// (Kirsch-Mitzenmacher), mixed so nearby values don't correlate.
+		h1, h2 := sum&0xffffffff, sum>>32|1
+		for i := range sig {
+			v := uint32(mix(h1 + uint64(i)*h2))
+			if v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Similarity estimates the Jaccard similarity of the sets behind s and o.
+func (s *Signature) Similarity(o *Signature) float64 {
+	same := 0
+	for i := range s {
+		if s[i] == o[i] {
+			same++
+		}
+	}
+	return float64(same) / numHashes
+}
+
+// bands returns one bucket key per band, so that signatures agreeing on a
+// whole band share its key.
+func (s *Signature) bands() [numBands]uint64 {
+	var keys [numBands]uint64
+	for b := range keys {
+		key := uint64(b)
+		for _, v := range s[b*bandRows : (b+1)*bandRows] {
+			key = mix(key ^ uint64(v))
+		}
+		keys[b] = key
+	}
+	return keys
+}
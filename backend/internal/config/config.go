@@ -1,17 +1,68 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port            string
-	DatabaseURL     string
-	GitHubToken     string
-	RefreshInterval time.Duration
+	Port                    string
+	DatabaseURL             string
+	GitHubTokens            []string // pooled personal access tokens
+	GitHubAppID             string   // GitHub App ID or client ID; enables installation auth
+	GitHubAppInstallationID int64
+	GitHubAppKeyFile        string // path to the app's PEM private key
+	GitHubAppAPIURL         string // where installation tokens are minted; defaults to GitHubAPIURL
+	GitHubAPIURL            string // REST root; empty means github.com, set it for GitHub Enterprise Server
+	GitHubGraphQLURL        string // derived from GitHubAPIURL when empty
+	GitHubUploadURL         string // derived from GitHubAPIURL when empty
+	GitHubAPIVersion        string // X-GitHub-Api-Version; empty uses the client default, "none" omits it
+	GitHubFixtures          string // "record" saves GitHub traffic as fixtures, "replay" serves it back offline
+	GitHubFixturesDir       string
+	GitHubSeed              int64  // seeds sampling in record/replay mode so runs repeat exactly
+	GitHubAPI               string // "rest" (default) or "graphql"
+	GitHubCacheDir          string // enables conditional requests when set
+	GitHubCacheMaxBytes     int64  // size the response cache is kept under
+	RefreshInterval         time.Duration
+	RefreshTimeout          time.Duration // deadline for one refresh run across all sources
+	GitHubConcurrency       int           // sampled GitHub searches run at once
+	Sources                 []string      // code hosts to refresh from: github, gitlab, gitea, bitbucket
+	GitLabURL               string
+	GitLabToken             string
+	GiteaURL                string // a Gitea or Forgejo instance; defaults to Codeberg
+	GiteaToken              string
+	BitbucketURL            string
+	DiscoveryMode           string        // "sample" (default) or "crawl"
+	CrawlPages              int           // search requests per refresh in crawl mode
+	RevalidateEvery         time.Duration // how often the revalidation job runs
+	RevalidateBatch         int           // repos checked per revalidation run
+	RevalidateAfter         time.Duration // minimum age of the last check before a recheck
+	ReevaluateAfter         time.Duration // age of a stored score before it is recomputed
+	EnrichEvery             time.Duration // how often enrichment jobs (README backfill, owners, ...) run
+	EnrichBatch             int           // repos processed per enrichment run
+	AdminToken              string        // bearer token for /api/admin; admin API is off when empty
+
+	// Scorer names the idea score strategy and ScoreWeights overrides its
+	// default weights; see package scoring.
+	Scorer       string
+	ScoreWeights map[string]float64
+
+	// ClassifierModel is a model file written by cmd/classifier; repos are
+	// categorized by keywords alone when empty. Below
+	// ClassifierMinConfidence, keywords decide anyway.
+	ClassifierModel         string
+	ClassifierMinConfidence float64
+
+	// ClusterEvery is how often near-duplicate repos are regrouped into idea
+	// clusters, and ClusterThreshold how similar, 0..1, a repo has to be to
+	// its cluster's representative to join it.
+	ClusterEvery     time.Duration
+	ClusterThreshold float64
 }
 
 func Load() (*Config, error) {
@@ -37,10 +88,181 @@ func Load() (*Config, error) {
 		refreshInterval = 6 * time.Hour
 	}
 
+	refreshTimeout := durationEnv("REFRESH_TIMEOUT", 10*time.Minute)
+	concurrency, err := strconv.Atoi(os.Getenv("GITHUB_CONCURRENCY"))
+	if err != nil || concurrency < 1 {
+		concurrency = 3
+	}
+
+	cacheMaxMB, err := strconv.ParseInt(os.Getenv("GITHUB_CACHE_MAX_MB"), 10, 64)
+	if err != nil || cacheMaxMB < 1 {
+		cacheMaxMB = 256
+	}
+
+	githubAPI := os.Getenv("GITHUB_API")
+	if githubAPI != "graphql" {
+		githubAPI = "rest"
+	}
+
+	discoveryMode := os.Getenv("DISCOVERY_MODE")
+	if discoveryMode != "crawl" {
+		discoveryMode = "sample"
+	}
+
+	crawlPages, err := strconv.Atoi(os.Getenv("CRAWL_PAGES_PER_RUN"))
+	if err != nil || crawlPages < 1 {
+		crawlPages = 30
+	}
+
+	revalidateEvery := durationEnv("REVALIDATE_INTERVAL", time.Hour)
+	revalidateAfter := durationEnv("REVALIDATE_AFTER", 7*24*time.Hour)
+	revalidateBatch, err := strconv.Atoi(os.Getenv("REVALIDATE_BATCH"))
+	if err != nil || revalidateBatch < 1 {
+		revalidateBatch = 100
+	}
+
+	reevaluateAfter := durationEnv("REEVALUATE_AFTER", 30*24*time.Hour)
+
+	enrichEvery := durationEnv("ENRICH_INTERVAL", 30*time.Minute)
+	enrichBatch, err := strconv.Atoi(os.Getenv("ENRICH_BATCH"))
+	if err != nil || enrichBatch < 1 {
+		enrichBatch = 50
+	}
+
+	// SCORE_WEIGHTS takes comma-separated name=value pairs, e.g. "stars=9,forks=3".
+	scoreWeights := make(map[string]float64)
+	for _, pair := range strings.Split(os.Getenv("SCORE_WEIGHTS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid weight %q in SCORE_WEIGHTS, want name=number", pair)
+		}
+		scoreWeights[strings.TrimSpace(name)] = w
+	}
+
+	minConfidence := 0.7
+	if v := os.Getenv("CLASSIFIER_MIN_CONFIDENCE"); v != "" {
+		minConfidence, err = strconv.ParseFloat(v, 64)
+		if err != nil || minConfidence < 0 || minConfidence > 1 {
+			return nil, fmt.Errorf("invalid CLASSIFIER_MIN_CONFIDENCE %q, want a number from 0 to 1", v)
+		}
+	}
+
+	clusterEvery := durationEnv("CLUSTER_INTERVAL", 6*time.Hour)
+	clusterThreshold := 0.5
+	if v := os.Getenv("CLUSTER_THRESHOLD"); v != "" {
+		clusterThreshold, err = strconv.ParseFloat(v, 64)
+		if err != nil || clusterThreshold <= 0 || clusterThreshold > 1 {
+			return nil, fmt.Errorf("invalid CLUSTER_THRESHOLD %q, want a number above 0, up to 1", v)
+		}
+	}
+
+	// GITHUB_TOKENS takes a comma-separated list; GITHUB_TOKEN still works
+	// on its own and joins the pool.
+	var tokens []string
+	for _, t := range strings.Split(os.Getenv("GITHUB_TOKEN")+","+os.Getenv("GITHUB_TOKENS"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+
+	appID := os.Getenv("GITHUB_APP_ID")
+	var installationID int64
+	if appID != "" {
+		installationID, err = strconv.ParseInt(os.Getenv("GITHUB_APP_INSTALLATION_ID"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("GITHUB_APP_ID is set but GITHUB_APP_INSTALLATION_ID is not a valid ID: %w", err)
+		}
+		if os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE") == "" {
+			return nil, fmt.Errorf("GITHUB_APP_ID is set but GITHUB_APP_PRIVATE_KEY_FILE is not")
+		}
+	}
+
+	fixtures := os.Getenv("GITHUB_FIXTURES")
+	if fixtures != "record" && fixtures != "replay" {
+		fixtures = ""
+	}
+	seed, err := strconv.ParseInt(os.Getenv("GITHUB_FIXTURES_SEED"), 10, 64)
+	if err != nil {
+		seed = 1
+	}
+
+	sources := []string{"github"}
+	if v := os.Getenv("SOURCES"); v != "" {
+		sources = nil
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			switch name {
+			case "github", "gitlab", "gitea", "bitbucket":
+				sources = append(sources, name)
+			case "":
+			default:
+				return nil, fmt.Errorf("unknown source %q in SOURCES", name)
+			}
+		}
+	}
+
 	return &Config{
-		Port:            port,
-		DatabaseURL:     dbURL,
-		GitHubToken:     os.Getenv("GITHUB_TOKEN"),
-		RefreshInterval: refreshInterval,
+		Port:                    port,
+		DatabaseURL:             dbURL,
+		GitHubTokens:            tokens,
+		GitHubAppID:             appID,
+		GitHubAppInstallationID: installationID,
+		GitHubAppKeyFile:        os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"),
+		GitHubAppAPIURL:         os.Getenv("GITHUB_APP_API_URL"),
+		GitHubAPIURL:            os.Getenv("GITHUB_API_URL"),
+		GitHubGraphQLURL:        os.Getenv("GITHUB_GRAPHQL_URL"),
+		GitHubUploadURL:         os.Getenv("GITHUB_UPLOAD_URL"),
+		GitHubAPIVersion:        os.Getenv("GITHUB_API_VERSION"),
+		GitHubAPI:               githubAPI,
+		GitHubCacheDir:          os.Getenv("GITHUB_CACHE_DIR"),
+		GitHubCacheMaxBytes:     cacheMaxMB << 20,
+		RefreshInterval:         refreshInterval,
+		RefreshTimeout:          refreshTimeout,
+		GitHubConcurrency:       concurrency,
+		GitHubFixtures:          fixtures,
+		GitHubFixturesDir:       envDefault("GITHUB_FIXTURES_DIR", "testdata/github"),
+		GitHubSeed:              seed,
+		Sources:                 sources,
+		GitLabURL:               envDefault("GITLAB_URL", "https://gitlab.com"),
+		GitLabToken:             os.Getenv("GITLAB_TOKEN"),
+		GiteaURL:                envDefault("GITEA_URL", "https://codeberg.org"),
+		GiteaToken:              os.Getenv("GITEA_TOKEN"),
+		BitbucketURL:            envDefault("BITBUCKET_URL", "https://api.bitbucket.org/2.0"),
+		DiscoveryMode:           discoveryMode,
+		CrawlPages:              crawlPages,
+		RevalidateEvery:         revalidateEvery,
+		RevalidateBatch:         revalidateBatch,
+		RevalidateAfter:         revalidateAfter,
+		ReevaluateAfter:         reevaluateAfter,
+		EnrichEvery:             enrichEvery,
+		EnrichBatch:             enrichBatch,
+		AdminToken:              os.Getenv("ADMIN_TOKEN"),
+		Scorer:                  envDefault("SCORER", "weighted"),
+		ScoreWeights:            scoreWeights,
+		ClassifierModel:         os.Getenv("CLASSIFIER_MODEL"),
+		ClassifierMinConfidence: minConfidence,
+		ClusterEvery:            clusterEvery,
+		ClusterThreshold:        clusterThreshold,
 	}, nil
 }
+
+// durationEnv parses a duration variable, falling back to def when unset or invalid.
+func durationEnv(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+// envDefault returns the variable's value, or def when it is unset.
+func envDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+type CategoryStore struct {
+	db *sql.DB
+}
+
+func NewCategoryStore(db *sql.DB) *CategoryStore {
+	return &CategoryStore{db: db}
+}
+
+// legacyDefaultCategories are the defaults from before subcategories, which
+// were all added on first start without being recorded as seeded.
+var legacyDefaultCategories = []string{"mobile", "game", "ai", "web", "data", "dev-tools", models.CategoryOther}
+
+// Seed adds the defaults that haven't been added before, parents first, and
+// skips subcategories whose parent was deleted. A default that was added
+// once is left to the admin API, even if it was since deleted. A default
+// that would make the taxonomy invalid alongside the admin's categories,
+// such as by claiming a stack another category claims, is skipped and
+// tried again on the next start.
+func (s *CategoryStore) Seed(defaults []models.Category) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current []models.Category
+	rows, err := tx.Query("SELECT " + categoryColumns + " FROM categories")
+	if err != nil {
+		return fmt.Errorf("listing categories: %w", err)
+	}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("scanning category: %w", err)
+		}
+		current = append(current, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("listing categories: %w", err)
+	}
+	exists := make(map[string]bool, len(current))
+	for _, c := range current {
+		exists[c.ID] = true
+	}
+
+	for _, c := range defaults {
+		var seeded bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM seeded_categories WHERE id = $1)", c.ID).Scan(&seeded)
+		if err != nil {
+			return fmt.Errorf("checking category %s: %w", c.ID, err)
+		}
+		if seeded {
+			continue
+		}
+		if c.Parent != "" && !exists[c.Parent] {
+			continue
+		}
+		if !exists[c.ID] {
+			if _, err := models.NewTaxonomy(append(current[:len(current):len(current)], c)); err != nil {
+				log.Printf("Not adding default category %s: %v", c.ID, err)
+				continue
+			}
+			_, err = tx.Exec(`
+				INSERT INTO categories (id, label, icon, description, sort_order, parent_id, keywords, stack)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+				c.ID, c.Label, c.Icon, c.Description, c.SortOrder, nullString(c.Parent),
+				pq.Array(nonNil(c.Keywords)), pq.Array(nonNil(c.Stack)),
+			)
+			if err != nil {
+				return fmt.Errorf("seeding category %s: %w", c.ID, err)
+			}
+			current = append(current, c)
+			exists[c.ID] = true
+		}
+		if _, err := tx.Exec("INSERT INTO seeded_categories (id) VALUES ($1)", c.ID); err != nil {
+			return fmt.Errorf("recording category %s: %w", c.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+const categoryColumns = `id, label, icon, description, sort_order, COALESCE(parent_id, ''),
+	keywords, stack, created_at, updated_at`
+
+func scanCategory(row interface{ Scan(...interface{}) error }) (models.Category, error) {
+	var c models.Category
+	err := row.Scan(&c.ID, &c.Label, &c.Icon, &c.Description, &c.SortOrder, &c.Parent,
+		pq.Array(&c.Keywords), pq.Array(&c.Stack), &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+// List returns every category in sort order.
+func (s *CategoryStore) List() ([]models.Category, error) {
+	rows, err := s.db.Query("SELECT " + categoryColumns + " FROM categories ORDER BY sort_order, id")
+	if err != nil {
+		return nil, fmt.Errorf("listing categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// Taxonomy compiles the stored categories.
+func (s *CategoryStore) Taxonomy() (*models.Taxonomy, error) {
+	categories, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return models.NewTaxonomy(categories)
+}
+
+func (s *CategoryStore) Get(id string) (models.Category, error) {
+	c, err := scanCategory(s.db.QueryRow("SELECT "+categoryColumns+" FROM categories WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *CategoryStore) Create(c models.Category) (models.Category, error) {
+	return scanCategory(s.db.QueryRow(`
+		INSERT INTO categories (id, label, icon, description, sort_order, parent_id, keywords, stack)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+categoryColumns,
+		c.ID, c.Label, c.Icon, c.Description, c.SortOrder, nullString(c.Parent),
+		pq.Array(nonNil(c.Keywords)), pq.Array(nonNil(c.Stack)),
+	))
+}
+
+func (s *CategoryStore) Update(c models.Category) (models.Category, error) {
+	updated, err := scanCategory(s.db.QueryRow(`
+		UPDATE categories SET
+			label = $2, icon = $3, description = $4, sort_order = $5, parent_id = $6,
+			keywords = $7, stack = $8, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+categoryColumns,
+		c.ID, c.Label, c.Icon, c.Description, c.SortOrder, nullString(c.Parent),
+		pq.Array(nonNil(c.Keywords)), pq.Array(nonNil(c.Stack)),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return updated, ErrNotFound
+	}
+	return updated, err
+}
+
+// Delete removes a category. Repos labeled with it keep the label until
+// they are recategorized.
+func (s *CategoryStore) Delete(id string) error {
+	res, err := s.db.Exec("DELETE FROM categories WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// nonNil returns s, or an empty slice for nil, for NOT NULL array columns.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// clusterRank orders a cluster's repos best first. The clustering job picks
+// representatives in this order, and collapsed listings show the best repo
+// of each cluster that matches their filters.
+const clusterRank = "idea_score DESC, stargazers DESC, repos.source, repos.id"
+
+// ClusterStore keeps the idea clusters of near-duplicate repos.
+type ClusterStore struct {
+	db *sql.DB
+}
+
+func NewClusterStore(db *sql.DB) *ClusterStore {
+	return &ClusterStore{db: db}
+}
+
+// Clusterable returns every listed repo, best first, with its current
+// cluster.
+func (s *ClusterStore) Clusterable() ([]models.Repo, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + repoColumns + `
+		FROM repos
+		WHERE ` + hiddenStatusCondition + `
+		ORDER BY ` + clusterRank)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos to cluster: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []models.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// Save replaces every cluster with clusters, whose Repos need only their
+// source and ID, in one transaction. A new cluster takes over the ID most of
+// its repos had, unless a larger cluster already took it, so links to a
+// cluster keep working while its idea stays the same. Repos in no cluster
+// lose theirs.
+func (s *ClusterStore) Save(clusters []models.Cluster) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	type repoKey struct {
+		source string
+		id     int64
+	}
+	previous := make(map[repoKey]int64)
+	rows, err := tx.Query("SELECT source, id, cluster_id FROM repos WHERE cluster_id IS NOT NULL")
+	if err != nil {
+		return fmt.Errorf("loading clusters: %w", err)
+	}
+	for rows.Next() {
+		var k repoKey
+		var clusterID int64
+		if err := rows.Scan(&k.source, &k.id, &clusterID); err != nil {
+			rows.Close()
+			return fmt.Errorf("scanning cluster: %w", err)
+		}
+		previous[k] = clusterID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("loading clusters: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE repos SET cluster_id = NULL, cluster_size = 0 WHERE cluster_id IS NOT NULL"); err != nil {
+		return fmt.Errorf("clearing clusters: %w", err)
+	}
+
+	order := make([]int, len(clusters))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(clusters[order[i]].Repos) > len(clusters[order[j]].Repos) })
+
+	kept := []int64{}
+	taken := make(map[int64]bool)
+	var sources []string
+	var repoIDs, clusterIDs, sizes []int64
+	for _, i := range order {
+		c := clusters[i]
+		votes := make(map[int64]int)
+		for _, r := range c.Repos {
+			if id, ok := previous[repoKey{r.SourceName(), r.ID}]; ok && !taken[id] {
+				votes[id]++
+			}
+		}
+		var id int64
+		for candidate, n := range votes {
+			if n > votes[id] || n == votes[id] && candidate < id {
+				id = candidate
+			}
+		}
+
+		terms := pq.Array(nonNil(c.Terms))
+		if id != 0 {
+			_, err = tx.Exec("UPDATE clusters SET terms = $2, size = $3, updated_at = NOW() WHERE id = $1", id, terms, len(c.Repos))
+		} else {
+			err = tx.QueryRow("INSERT INTO clusters (terms, size) VALUES ($1, $2) RETURNING id", terms, len(c.Repos)).Scan(&id)
+		}
+		if err != nil {
+			return fmt.Errorf("saving cluster: %w", err)
+		}
+		taken[id] = true
+		kept = append(kept, id)
+
+		for _, r := range c.Repos {
+			sources = append(sources, r.SourceName())
+			repoIDs = append(repoIDs, r.ID)
+			clusterIDs = append(clusterIDs, id)
+			sizes = append(sizes, int64(len(c.Repos)))
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE repos SET cluster_id = u.cluster_id, cluster_size = u.size
+		FROM unnest($1::text[], $2::bigint[], $3::bigint[], $4::int[]) AS u(source, id, cluster_id, size)
+		WHERE repos.source = u.source AND repos.id = u.id`,
+		pq.Array(sources), pq.Array(repoIDs), pq.Array(clusterIDs), pq.Array(sizes))
+	if err != nil {
+		return fmt.Errorf("assigning repos to clusters: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM clusters WHERE id <> ALL($1)", pq.Array(kept)); err != nil {
+		return fmt.Errorf("deleting old clusters: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Get returns a cluster with all of its repos, best first, whatever their
+// status.
+func (s *ClusterStore) Get(id int64) (models.Cluster, error) {
+	var c models.Cluster
+	err := s.db.QueryRow("SELECT id, terms, size, updated_at FROM clusters WHERE id = $1", id).
+		Scan(&c.ID, pq.Array(&c.Terms), &c.Size, &c.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrNotFound
+	}
+	if err != nil {
+		return c, fmt.Errorf("loading cluster %d: %w", id, err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+repoColumns+`, `+ownerColumns+`
+		FROM `+reposWithOwners+`
+		WHERE repos.cluster_id = $1
+		ORDER BY `+clusterRank, id)
+	if err != nil {
+		return c, fmt.Errorf("listing cluster %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	c.Repos = []models.Repo{}
+	now := time.Now()
+	for rows.Next() {
+		r, err := scanListedRepo(rows, false, now)
+		if err != nil {
+			return c, fmt.Errorf("scanning repo: %w", err)
+		}
+		c.Repos = append(c.Repos, r)
+	}
+	return c, rows.Err()
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// CrawlStore persists crawl windows and their page cursors.
+type CrawlStore struct {
+	db *sql.DB
+}
+
+func NewCrawlStore(db *sql.DB) *CrawlStore {
+	return &CrawlStore{db: db}
+}
+
+const windowColumns = `id, query_id, pushed_from, pushed_to, created_from, created_to,
+	next_page, total_count, done, updated_at`
+
+// Windows returns a query's windows in crawl order: oldest pushed range first.
+func (s *CrawlStore) Windows(queryID int64) ([]models.CrawlWindow, error) {
+	rows, err := s.db.Query("SELECT "+windowColumns+` FROM crawl_windows
+		WHERE query_id = $1
+		ORDER BY pushed_from, created_from`, queryID)
+	if err != nil {
+		return nil, fmt.Errorf("listing crawl windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []models.CrawlWindow
+	for rows.Next() {
+		var w models.CrawlWindow
+		if err := rows.Scan(&w.ID, &w.QueryID, &w.PushedFrom, &w.PushedTo,
+			&w.CreatedFrom, &w.CreatedTo, &w.NextPage, &w.TotalCount, &w.Done, &w.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning crawl window: %w", err)
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// SaveWindow inserts a new window (ID 0) or updates its cursor.
+func (s *CrawlStore) SaveWindow(w *models.CrawlWindow) error {
+	if w.ID == 0 {
+		return s.db.QueryRow(`
+			INSERT INTO crawl_windows (query_id, pushed_from, pushed_to, created_from,
+				created_to, next_page, total_count, done)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			w.QueryID, w.PushedFrom, w.PushedTo, w.CreatedFrom, w.CreatedTo,
+			w.NextPage, w.TotalCount, w.Done,
+		).Scan(&w.ID)
+	}
+	_, err := s.db.Exec(`
+		UPDATE crawl_windows SET next_page = $2, total_count = $3, done = $4, updated_at = NOW()
+		WHERE id = $1`,
+		w.ID, w.NextPage, w.TotalCount, w.Done,
+	)
+	return err
+}
+
+// SplitWindow replaces parent with its children in one transaction, so an
+// interrupted run never loses part of the date range. The children's IDs are
+// filled in.
+func (s *CrawlStore) SplitWindow(parent models.CrawlWindow, children []models.CrawlWindow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM crawl_windows WHERE id = $1", parent.ID); err != nil {
+		return fmt.Errorf("deleting window: %w", err)
+	}
+	for i := range children {
+		w := &children[i]
+		if err := tx.QueryRow(`
+			INSERT INTO crawl_windows (query_id, pushed_from, pushed_to, created_from,
+				created_to, next_page, total_count, done)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			w.QueryID, w.PushedFrom, w.PushedTo, w.CreatedFrom, w.CreatedTo,
+			w.NextPage, w.TotalCount, w.Done,
+		).Scan(&w.ID); err != nil {
+			return fmt.Errorf("inserting window: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ResetWindows drops a query's windows so the next run starts a fresh pass.
+func (s *CrawlStore) ResetWindows(queryID int64) error {
+	_, err := s.db.Exec("DELETE FROM crawl_windows WHERE query_id = $1", queryID)
+	return err
+}
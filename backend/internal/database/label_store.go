@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// LabelStore keeps the hand-assigned categories classifiers learn from.
+type LabelStore struct {
+	db *sql.DB
+}
+
+func NewLabelStore(db *sql.DB) *LabelStore {
+	return &LabelStore{db: db}
+}
+
+// Save sets a repo's categories, primary first, replacing any earlier ones.
+func (s *LabelStore) Save(source string, id int64, categories []string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO repo_labels (source, repo_id, categories, labeled_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (source, repo_id) DO UPDATE SET
+			categories = EXCLUDED.categories,
+			labeled_at = EXCLUDED.labeled_at`,
+		source, id, pq.Array(categories),
+	)
+	return err
+}
+
+// Labeled returns every labeled repo that is stored, in a stable order.
+// Labels of repos that aren't are skipped.
+func (s *LabelStore) Labeled() ([]models.LabeledRepo, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + repoColumns + `, l.categories
+		FROM repo_labels l
+		JOIN repos ON repos.source = l.source AND repos.id = l.repo_id
+		ORDER BY repos.source, repos.id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing labeled repos: %w", err)
+	}
+	defer rows.Close()
+
+	var labeled []models.LabeledRepo
+	for rows.Next() {
+		var categories []string
+		r, err := scanRepo(rows, pq.Array(&categories))
+		if err != nil {
+			return nil, fmt.Errorf("scanning labeled repo: %w", err)
+		}
+		labeled = append(labeled, models.LabeledRepo{Repo: r, Categories: categories})
+	}
+	return labeled, rows.Err()
+}
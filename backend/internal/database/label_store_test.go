@@ -0,0 +1,86 @@
+package database
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// TestRepoColumnsQualified checks that every column in repoColumns names
+// its table, since queries such as LabelStore.Labeled join repos with tables
+// that have columns of the same name.
+func TestRepoColumnsQualified(t *testing.T) {
+	rest := regexp.MustCompile(`'[^']*'`).ReplaceAllString(repoColumns, "")
+	rest = regexp.MustCompile(`repos\.[a-z_]+`).ReplaceAllString(rest, "")
+	rest = strings.ReplaceAll(rest, "COALESCE", "")
+	if bare := regexp.MustCompile(`[a-z_]+`).FindAllString(rest, -1); len(bare) > 0 {
+		t.Errorf("repoColumns has unqualified columns %q", bare)
+	}
+	if n, want := strings.Count(repoColumns, "repos."), 46; n != want {
+		t.Errorf("repoColumns has %d columns, scanRepo reads %d", n, want)
+	}
+}
+
+// TestLabeled runs LabelStore.Labeled against the Postgres database in
+// TEST_DATABASE_URL, which it migrates and writes to.
+func TestLabeled(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := Connect(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	const source = "test-labels"
+	if _, err := db.Exec(`DELETE FROM repos WHERE source = $1`, source); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`DELETE FROM repo_labels WHERE source = $1`, source); err != nil {
+		t.Fatal(err)
+	}
+	pushed := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	repo := models.Repo{
+		Source: source, ID: 1, Name: "loader", FullName: "someone/loader", OwnerLogin: "someone",
+		HTMLURL: "https://example.com/someone/loader", Description: "Image loader",
+		PushedAt: pushed, CreatedAt: pushed.AddDate(-2, 0, 0), Category: "other",
+		Categories: []models.CategoryLabel{{ID: "other", Confidence: 1}},
+	}
+	if err := NewRepoStore(db).Upsert(repo); err != nil {
+		t.Fatal(err)
+	}
+	labels := NewLabelStore(db)
+	if err := labels.Save(source, 1, []string{"ai", "mobile"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := labels.Save(source, 2, []string{"game"}); err != nil {
+		t.Fatal(err)
+	}
+
+	labeled, err := labels.Labeled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []models.LabeledRepo
+	for _, l := range labeled {
+		if l.Repo.Source == source {
+			got = append(got, l)
+		}
+	}
+	if len(got) != 1 || got[0].Repo.ID != 1 || !reflect.DeepEqual(got[0].Categories, []string{"ai", "mobile"}) {
+		t.Errorf("Labeled = %+v, want repo 1 labeled ai and mobile only", got)
+	}
+	if len(got) == 1 && got[0].Repo.Category != "other" {
+		t.Errorf("labeled repo has category %q from the labels, want its own other", got[0].Repo.Category)
+	}
+}
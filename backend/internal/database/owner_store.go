@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+type OwnerStore struct {
+	db *sql.DB
+}
+
+func NewOwnerStore(db *sql.DB) *OwnerStore {
+	return &OwnerStore{db: db}
+}
+
+// Due returns the logins of a source's fossil owners that have never been
+// enriched or were last enriched before olderThan, owners of the
+// best-scoring fossils first. Owners whose enrichment last failed after
+// failedBefore are left out until then.
+func (s *OwnerStore) Due(source string, olderThan, failedBefore time.Time, limit int) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT r.owner_login
+		FROM repos r
+		LEFT JOIN owners o ON o.source = r.source AND o.login = r.owner_login
+		LEFT JOIN owner_failures f ON f.source = r.source AND f.login = r.owner_login
+		WHERE r.source = $1 AND r.status <> $2 AND (o.enriched_at IS NULL OR o.enriched_at < $3)
+			AND (f.failed_at IS NULL OR f.failed_at < $4)
+		GROUP BY r.owner_login
+		ORDER BY MAX(r.idea_score) DESC
+		LIMIT $5`, source, models.StatusDeleted, olderThan, failedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing owners to enrich: %w", err)
+	}
+	defer rows.Close()
+
+	var logins []string
+	for rows.Next() {
+		var login string
+		if err := rows.Scan(&login); err != nil {
+			return nil, err
+		}
+		logins = append(logins, login)
+	}
+	return logins, rows.Err()
+}
+
+// MarkFailed records that enriching an owner failed, so Due skips it for a
+// while.
+func (s *OwnerStore) MarkFailed(source, login string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO owner_failures (source, login) VALUES ($1, $2)
+		ON CONFLICT (source, login) DO UPDATE SET failed_at = NOW()`, source, login)
+	return err
+}
+
+// Save stores an owner's enrichment, replacing any earlier one, and clears
+// any failure recorded for it.
+func (s *OwnerStore) Save(o models.Owner) error {
+	if _, err := s.db.Exec("DELETE FROM owner_failures WHERE source = $1 AND login = $2", o.Source, o.Login); err != nil {
+		return fmt.Errorf("clearing owner failure: %w", err)
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO owners (source, login, owner_type, account_created_at, last_active_at, stale_repos, enriched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (source, login) DO UPDATE SET
+			owner_type = EXCLUDED.owner_type,
+			account_created_at = EXCLUDED.account_created_at,
+			last_active_at = EXCLUDED.last_active_at,
+			stale_repos = EXCLUDED.stale_repos,
+			enriched_at = EXCLUDED.enriched_at`,
+		o.Source, o.Login, o.Type, o.AccountCreatedAt, o.LastActiveAt, o.StaleRepos,
+	)
+	return err
+}
@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"log"
 
-	_ "github.com/lib/pq"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+	"github.com/lib/pq"
 )
 
 func Connect(databaseURL string) (*sql.DB, error) {
@@ -28,7 +29,8 @@ func Connect(databaseURL string) (*sql.DB, error) {
 func Migrate(db *sql.DB) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS repos (
-		id              BIGINT PRIMARY KEY,
+		source          TEXT NOT NULL DEFAULT 'github',
+		id              BIGINT NOT NULL,
 		name            TEXT NOT NULL,
 		full_name       TEXT NOT NULL,
 		owner_login     TEXT NOT NULL,
@@ -42,15 +44,225 @@ func Migrate(db *sql.DB) error {
 		pushed_at       TIMESTAMPTZ NOT NULL,
 		created_at      TIMESTAMPTZ NOT NULL,
 		idea_score      INTEGER NOT NULL DEFAULT 0,
+		score_version   TEXT NOT NULL DEFAULT '',
+		score_breakdown JSONB,
+		revivability_score INTEGER,
 		category        TEXT NOT NULL DEFAULT 'other',
-		fetched_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		categories      TEXT[] NOT NULL DEFAULT '{}',
+		category_confidences DOUBLE PRECISION[],
+		category_version TEXT NOT NULL DEFAULT '',
+		evaluated_at    TIMESTAMPTZ,
+		cluster_id      BIGINT,
+		cluster_size    INTEGER NOT NULL DEFAULT 0,
+		fetched_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		license         TEXT,
+		license_class   TEXT,
+		archived        BOOLEAN NOT NULL DEFAULT FALSE,
+		open_issues     INTEGER NOT NULL DEFAULT 0,
+		disk_usage_kb   INTEGER NOT NULL DEFAULT 0,
+		last_commit_at  TIMESTAMPTZ,
+		last_commit_message TEXT,
+		status          TEXT NOT NULL DEFAULT 'fossil',
+		checked_at      TIMESTAMPTZ,
+		readme          TEXT,
+		readme_excerpt  TEXT,
+		readme_images   TEXT[],
+		readme_fetched_at TIMESTAMPTZ,
+		activity_start  DATE,
+		activity_weeks  INTEGER[],
+		peak_week       DATE,
+		peak_commits    INTEGER,
+		months_peak_to_death DOUBLE PRECISION,
+		contributors    INTEGER,
+		activity_fetched_at TIMESTAMPTZ,
+		stack           TEXT[] NOT NULL DEFAULT '{}',
+		stack_fetched_at TIMESTAMPTZ,
+		stack_attempted_at TIMESTAMPTZ,
+		dependencies_updated_at TIMESTAMPTZ,
+		dependencies_fetched_at TIMESTAMPTZ,
+		has_tests       BOOLEAN,
+		has_ci          BOOLEAN,
+		build_status    TEXT,
+		health_fetched_at TIMESTAMPTZ,
+		PRIMARY KEY (source, id)
 	);
 
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS license TEXT;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS license_class TEXT;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS open_issues INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS disk_usage_kb INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS last_commit_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS last_commit_message TEXT;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'fossil';
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS checked_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS readme TEXT;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS readme_excerpt TEXT;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS readme_images TEXT[];
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS readme_fetched_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS activity_start DATE;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS activity_weeks INTEGER[];
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS peak_week DATE;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS peak_commits INTEGER;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS months_peak_to_death DOUBLE PRECISION;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS contributors INTEGER;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS activity_fetched_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS stack TEXT[] NOT NULL DEFAULT '{}';
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS stack_fetched_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS stack_attempted_at TIMESTAMPTZ;
+	-- dependencies_fetched_at is when the manifests were last read along with
+	-- their history; stacks read before that have to be read again.
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS dependencies_updated_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS dependencies_fetched_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS score_version TEXT NOT NULL DEFAULT '';
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS score_breakdown JSONB;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS revivability_score INTEGER;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS has_tests BOOLEAN;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS has_ci BOOLEAN;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS build_status TEXT;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS health_fetched_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS categories TEXT[] NOT NULL DEFAULT '{}';
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS category_confidences DOUBLE PRECISION[];
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS category_version TEXT NOT NULL DEFAULT '';
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMPTZ;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS cluster_id BIGINT;
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS cluster_size INTEGER NOT NULL DEFAULT 0;
+
+	-- Rows from before multi-label categories keep their single category
+	-- until re-evaluation labels them properly.
+	UPDATE repos SET categories = ARRAY[category] WHERE categories = '{}';
+
+	-- IDs are only unique per host: repos created before multi-source support
+	-- are GitHub's, and their primary key widens to (source, id).
+	ALTER TABLE repos ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'github';
+	DO $$
+	BEGIN
+		IF (SELECT array_length(conkey, 1) FROM pg_constraint
+			WHERE conrelid = 'repos'::regclass AND contype = 'p') = 1 THEN
+			ALTER TABLE repos DROP CONSTRAINT repos_pkey;
+			ALTER TABLE repos ADD PRIMARY KEY (source, id);
+		END IF;
+	END $$;
+
 	CREATE INDEX IF NOT EXISTS idx_repos_category ON repos(category);
+	CREATE INDEX IF NOT EXISTS idx_repos_categories ON repos USING GIN (categories);
 	CREATE INDEX IF NOT EXISTS idx_repos_idea_score ON repos(idea_score DESC);
+	CREATE INDEX IF NOT EXISTS idx_repos_revivability_score ON repos(revivability_score DESC);
 	CREATE INDEX IF NOT EXISTS idx_repos_stargazers ON repos(stargazers DESC);
 	CREATE INDEX IF NOT EXISTS idx_repos_pushed_at ON repos(pushed_at ASC);
 	CREATE INDEX IF NOT EXISTS idx_repos_fetched_at ON repos(fetched_at);
+	CREATE INDEX IF NOT EXISTS idx_repos_status ON repos(status);
+	CREATE INDEX IF NOT EXISTS idx_repos_cluster_id ON repos(cluster_id);
+	CREATE INDEX IF NOT EXISTS idx_repos_source ON repos(source);
+	CREATE INDEX IF NOT EXISTS idx_repos_months_peak_to_death ON repos(months_peak_to_death);
+	CREATE INDEX IF NOT EXISTS idx_repos_license_class ON repos(license_class);
+	CREATE INDEX IF NOT EXISTS idx_repos_stack ON repos USING GIN (stack);
+	CREATE INDEX IF NOT EXISTS idx_repos_checked_at ON repos(checked_at ASC NULLS FIRST);
+
+	CREATE TABLE IF NOT EXISTS discovery_queries (
+		id               BIGSERIAL PRIMARY KEY,
+		query            TEXT NOT NULL UNIQUE,
+		stale_after_days INTEGER NOT NULL DEFAULT 730,
+		min_stars        INTEGER NOT NULL DEFAULT 6,
+		sort_options     TEXT[] NOT NULL DEFAULT '{stars,updated,best-match}',
+		enabled          BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS query_runs (
+		id             BIGSERIAL PRIMARY KEY,
+		query_id       BIGINT NOT NULL REFERENCES discovery_queries(id) ON DELETE CASCADE,
+		run_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		fetched        INTEGER NOT NULL DEFAULT 0,
+		new_repos      INTEGER NOT NULL DEFAULT 0,
+		duplicates     INTEGER NOT NULL DEFAULT 0,
+		avg_idea_score DOUBLE PRECISION NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_query_runs_query ON query_runs(query_id, run_at DESC);
+
+	-- Fossil authors, filled in by the owner enrichment job. Column names
+	-- other than source differ from repos' so the two can be joined freely.
+	CREATE TABLE IF NOT EXISTS owners (
+		source             TEXT NOT NULL,
+		login              TEXT NOT NULL,
+		owner_type         TEXT NOT NULL,
+		account_created_at TIMESTAMPTZ,
+		last_active_at     TIMESTAMPTZ,
+		stale_repos        INTEGER NOT NULL DEFAULT 0,
+		enriched_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (source, login)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_owners_last_active_at ON owners(last_active_at);
+	CREATE INDEX IF NOT EXISTS idx_repos_owner ON repos(source, owner_login);
+
+	CREATE TABLE IF NOT EXISTS crawl_windows (
+		id           BIGSERIAL PRIMARY KEY,
+		query_id     BIGINT NOT NULL REFERENCES discovery_queries(id) ON DELETE CASCADE,
+		pushed_from  DATE NOT NULL,
+		pushed_to    DATE NOT NULL,
+		created_from DATE NOT NULL,
+		created_to   DATE NOT NULL,
+		next_page    INTEGER NOT NULL DEFAULT 1,
+		total_count  INTEGER NOT NULL DEFAULT -1,
+		done         BOOLEAN NOT NULL DEFAULT FALSE,
+		updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_crawl_windows_query ON crawl_windows(query_id, pushed_from, created_from);
+
+	-- The taxonomy, seeded from models.DefaultCategories and edited through
+	-- the admin API. Categories with children can't be deleted.
+	CREATE TABLE IF NOT EXISTS categories (
+		id          TEXT PRIMARY KEY,
+		label       TEXT NOT NULL,
+		icon        TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		sort_order  INTEGER NOT NULL DEFAULT 0,
+		parent_id   TEXT REFERENCES categories(id),
+		keywords    TEXT[] NOT NULL DEFAULT '{}',
+		stack       TEXT[] NOT NULL DEFAULT '{}',
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	-- Hand-assigned categories, primary first, that classifiers are trained
+	-- and evaluated on. Labels outlive the repo rows they refer to.
+	CREATE TABLE IF NOT EXISTS repo_labels (
+		source     TEXT NOT NULL,
+		repo_id    BIGINT NOT NULL,
+		categories TEXT[] NOT NULL,
+		labeled_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (source, repo_id)
+	);
+
+	-- Default categories that have been added to the taxonomy, so defaults
+	-- introduced by an upgrade are added once and deleted ones stay deleted.
+	CREATE TABLE IF NOT EXISTS seeded_categories (
+		id        TEXT PRIMARY KEY,
+		seeded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	-- Groups of near-duplicate repos, regrouped by the clustering job. A
+	-- repo's cluster_id points here; repos without a near-duplicate have none.
+	CREATE TABLE IF NOT EXISTS clusters (
+		id         BIGSERIAL PRIMARY KEY,
+		terms      TEXT[] NOT NULL DEFAULT '{}',
+		size       INTEGER NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	-- Owners whose last enrichment failed, so they wait before being retried
+	-- instead of taking the batch's first slots every run.
+	CREATE TABLE IF NOT EXISTS owner_failures (
+		source    TEXT NOT NULL,
+		login     TEXT NOT NULL,
+		failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (source, login)
+	);
 	`
 
 	_, err := db.Exec(query)
@@ -58,6 +270,53 @@ func Migrate(db *sql.DB) error {
 		return fmt.Errorf("running migrations: %w", err)
 	}
 
+	// Classify licenses of rows stored before license classes existed. A
+	// NULL license was never fetched (rows from before licenses were, and
+	// hosts that don't report them), so it is unknown rather than none.
+	_, err = db.Exec(`
+		UPDATE repos SET license_class = CASE
+			WHEN license IS NULL THEN $6
+			WHEN license = '' THEN $1
+			WHEN LOWER(license) = ANY($2) THEN $3
+			WHEN LOWER(license) = ANY($4) THEN $5
+			ELSE $6
+		END
+		WHERE license_class IS NULL`,
+		models.LicenseNone,
+		pq.Array(models.LicenseIDs(models.LicensePermissive)), models.LicensePermissive,
+		pq.Array(models.LicenseIDs(models.LicenseCopyleft)), models.LicenseCopyleft,
+		models.LicenseUnknown,
+	)
+	if err != nil {
+		return fmt.Errorf("classifying licenses: %w", err)
+	}
+	// Repos found to have no license used to be stored with a NULL one; an
+	// empty license now says so, and NULL means it was never fetched.
+	_, err = db.Exec("UPDATE repos SET license = '' WHERE license IS NULL AND license_class = $1", models.LicenseNone)
+	if err != nil {
+		return fmt.Errorf("marking unlicensed repos: %w", err)
+	}
+	// Sources that don't report licenses used to store NOASSERTION, which
+	// only GitHub reports; they now leave the license NULL.
+	_, err = db.Exec("UPDATE repos SET license = NULL WHERE license = $1 AND source NOT IN ($2, $3)",
+		models.LicenseNoAssertion, models.SourceGitHub, models.SourceGitHubEnterprise)
+	if err != nil {
+		return fmt.Errorf("clearing unreported licenses: %w", err)
+	}
+	// Before seeded_categories existed, every default was added on first
+	// start, so a database upgraded from then has seeded them all, including
+	// ones an admin has since deleted.
+	_, err = db.Exec(`
+		INSERT INTO seeded_categories (id)
+		SELECT unnest($1::text[])
+		WHERE NOT EXISTS (SELECT 1 FROM seeded_categories) AND EXISTS (SELECT 1 FROM categories)
+		ON CONFLICT (id) DO NOTHING`,
+		pq.Array(legacyDefaultCategories),
+	)
+	if err != nil {
+		return fmt.Errorf("recording seeded categories: %w", err)
+	}
+
 	log.Println("Database migrations applied")
 	return nil
 }
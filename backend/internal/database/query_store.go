@@ -0,0 +1,175 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+	"github.com/lib/pq"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type QueryStore struct {
+	db *sql.DB
+}
+
+func NewQueryStore(db *sql.DB) *QueryStore {
+	return &QueryStore{db: db}
+}
+
+// SeedIfEmpty inserts the default queries on first start. Once the table has
+// any rows it is left to the admin API.
+func (s *QueryStore) SeedIfEmpty(defaults []models.DiscoveryQuery) error {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM discovery_queries").Scan(&count); err != nil {
+		return fmt.Errorf("counting queries: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	for _, q := range defaults {
+		if _, err := s.Create(q); err != nil {
+			return fmt.Errorf("seeding query %q: %w", q.Query, err)
+		}
+	}
+	return nil
+}
+
+const queryColumns = `id, query, stale_after_days, min_stars, sort_options, enabled, created_at, updated_at`
+
+func scanQuery(row interface{ Scan(...interface{}) error }) (models.DiscoveryQuery, error) {
+	var q models.DiscoveryQuery
+	err := row.Scan(&q.ID, &q.Query, &q.StaleAfterDays, &q.MinStars,
+		pq.Array(&q.SortOptions), &q.Enabled, &q.CreatedAt, &q.UpdatedAt)
+	return q, err
+}
+
+// List returns every query with its aggregated yield.
+func (s *QueryStore) List() ([]models.DiscoveryQuery, error) {
+	rows, err := s.db.Query(`
+		SELECT q.id, q.query, q.stale_after_days, q.min_stars, q.sort_options, q.enabled,
+			q.created_at, q.updated_at,
+			COUNT(r.id), COALESCE(SUM(r.fetched), 0), COALESCE(SUM(r.new_repos), 0),
+			COALESCE(SUM(r.duplicates), 0),
+			COALESCE(SUM(r.avg_idea_score * r.new_repos) / NULLIF(SUM(r.new_repos), 0), 0),
+			MAX(r.run_at)
+		FROM discovery_queries q
+		LEFT JOIN query_runs r ON r.query_id = q.id
+		GROUP BY q.id
+		ORDER BY q.id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := []models.DiscoveryQuery{}
+	for rows.Next() {
+		var q models.DiscoveryQuery
+		var y models.QueryYield
+		if err := rows.Scan(&q.ID, &q.Query, &q.StaleAfterDays, &q.MinStars,
+			pq.Array(&q.SortOptions), &q.Enabled, &q.CreatedAt, &q.UpdatedAt,
+			&y.Runs, &y.Fetched, &y.NewRepos, &y.Duplicates, &y.AvgIdeaScore, &y.LastRunAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning query: %w", err)
+		}
+		q.Yield = &y
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// Enabled returns the queries a refresh should run.
+func (s *QueryStore) Enabled() ([]models.DiscoveryQuery, error) {
+	rows, err := s.db.Query("SELECT " + queryColumns + " FROM discovery_queries WHERE enabled ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("listing enabled queries: %w", err)
+	}
+	defer rows.Close()
+
+	var queries []models.DiscoveryQuery
+	for rows.Next() {
+		q, err := scanQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning query: %w", err)
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+func (s *QueryStore) Get(id int64) (models.DiscoveryQuery, error) {
+	q, err := scanQuery(s.db.QueryRow("SELECT "+queryColumns+" FROM discovery_queries WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return q, ErrNotFound
+	}
+	return q, err
+}
+
+func (s *QueryStore) Create(q models.DiscoveryQuery) (models.DiscoveryQuery, error) {
+	return scanQuery(s.db.QueryRow(`
+		INSERT INTO discovery_queries (query, stale_after_days, min_stars, sort_options, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+queryColumns,
+		q.Query, q.StaleAfterDays, q.MinStars, pq.Array(q.SortOptions), q.Enabled,
+	))
+}
+
+func (s *QueryStore) Update(q models.DiscoveryQuery) (models.DiscoveryQuery, error) {
+	updated, err := scanQuery(s.db.QueryRow(`
+		UPDATE discovery_queries SET
+			query = $2, stale_after_days = $3, min_stars = $4, sort_options = $5,
+			enabled = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+queryColumns,
+		q.ID, q.Query, q.StaleAfterDays, q.MinStars, pq.Array(q.SortOptions), q.Enabled,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return updated, ErrNotFound
+	}
+	return updated, err
+}
+
+func (s *QueryStore) Delete(id int64) error {
+	res, err := s.db.Exec("DELETE FROM discovery_queries WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *QueryStore) RecordRun(run models.QueryRun) error {
+	_, err := s.db.Exec(`
+		INSERT INTO query_runs (query_id, fetched, new_repos, duplicates, avg_idea_score)
+		VALUES ($1, $2, $3, $4, $5)`,
+		run.QueryID, run.Fetched, run.NewRepos, run.Duplicates, run.AvgIdeaScore,
+	)
+	return err
+}
+
+// Runs returns the most recent runs of one query, newest first.
+func (s *QueryStore) Runs(queryID int64, limit int) ([]models.QueryRun, error) {
+	rows, err := s.db.Query(`
+		SELECT id, query_id, run_at, fetched, new_repos, duplicates, avg_idea_score
+		FROM query_runs WHERE query_id = $1
+		ORDER BY run_at DESC LIMIT $2`, queryID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.QueryRun{}
+	for rows.Next() {
+		var r models.QueryRun
+		if err := rows.Scan(&r.ID, &r.QueryID, &r.RunAt, &r.Fetched, &r.NewRepos,
+			&r.Duplicates, &r.AvgIdeaScore); err != nil {
+			return nil, fmt.Errorf("scanning run: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
@@ -2,6 +2,8 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -20,11 +22,18 @@ func NewRepoStore(db *sql.DB) *RepoStore {
 
 func (s *RepoStore) Upsert(repo models.Repo) error {
 	query := `
-	INSERT INTO repos (id, name, full_name, owner_login, owner_avatar, html_url,
+	INSERT INTO repos (source, id, name, full_name, owner_login, owner_avatar, html_url,
 		description, language, topics, stargazers, forks, pushed_at, created_at,
-		idea_score, category, fetched_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-	ON CONFLICT (id) DO UPDATE SET
+		idea_score, category, fetched_at, license, archived, open_issues,
+		disk_usage_kb, last_commit_at, last_commit_message, status, checked_at,
+		readme, readme_excerpt, readme_images, readme_fetched_at, stack, stack_fetched_at,
+		license_class, score_version, score_breakdown, revivability_score,
+		has_tests, has_ci, build_status, health_fetched_at, categories, category_confidences,
+		category_version, evaluated_at, dependencies_updated_at, dependencies_fetched_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+		$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34,
+		$35, $36, $37, $38, $39, $40, $41, $42, NOW(), $43, $31)
+	ON CONFLICT (source, id) DO UPDATE SET
 		name = EXCLUDED.name,
 		full_name = EXCLUDED.full_name,
 		owner_login = EXCLUDED.owner_login,
@@ -37,30 +46,244 @@ func (s *RepoStore) Upsert(repo models.Repo) error {
 		forks = EXCLUDED.forks,
 		pushed_at = EXCLUDED.pushed_at,
 		idea_score = EXCLUDED.idea_score,
+		score_version = EXCLUDED.score_version,
+		score_breakdown = EXCLUDED.score_breakdown,
+		revivability_score = EXCLUDED.revivability_score,
 		category = EXCLUDED.category,
-		fetched_at = EXCLUDED.fetched_at`
+		categories = EXCLUDED.categories,
+		category_confidences = EXCLUDED.category_confidences,
+		category_version = EXCLUDED.category_version,
+		evaluated_at = EXCLUDED.evaluated_at,
+		fetched_at = EXCLUDED.fetched_at,
+		license = EXCLUDED.license,
+		license_class = EXCLUDED.license_class,
+		archived = EXCLUDED.archived,
+		open_issues = EXCLUDED.open_issues,
+		disk_usage_kb = EXCLUDED.disk_usage_kb,
+		last_commit_at = COALESCE(EXCLUDED.last_commit_at, repos.last_commit_at),
+		last_commit_message = COALESCE(EXCLUDED.last_commit_message, repos.last_commit_message),
+		status = ` + upsertStatus + `,
+		checked_at = COALESCE(EXCLUDED.checked_at, repos.checked_at),
+		readme = CASE WHEN EXCLUDED.readme_fetched_at IS NULL THEN repos.readme ELSE EXCLUDED.readme END,
+		readme_excerpt = CASE WHEN EXCLUDED.readme_fetched_at IS NULL THEN repos.readme_excerpt ELSE EXCLUDED.readme_excerpt END,
+		readme_images = CASE WHEN EXCLUDED.readme_fetched_at IS NULL THEN repos.readme_images ELSE EXCLUDED.readme_images END,
+		readme_fetched_at = COALESCE(EXCLUDED.readme_fetched_at, repos.readme_fetched_at),
+		stack = CASE WHEN EXCLUDED.stack_fetched_at IS NULL THEN repos.stack ELSE EXCLUDED.stack END,
+		stack_fetched_at = COALESCE(EXCLUDED.stack_fetched_at, repos.stack_fetched_at),
+		dependencies_updated_at = CASE WHEN EXCLUDED.stack_fetched_at IS NULL THEN repos.dependencies_updated_at ELSE EXCLUDED.dependencies_updated_at END,
+		dependencies_fetched_at = COALESCE(EXCLUDED.dependencies_fetched_at, repos.dependencies_fetched_at),
+		has_tests = CASE WHEN EXCLUDED.health_fetched_at IS NULL THEN repos.has_tests ELSE EXCLUDED.has_tests END,
+		has_ci = CASE WHEN EXCLUDED.health_fetched_at IS NULL THEN repos.has_ci ELSE EXCLUDED.has_ci END,
+		build_status = CASE WHEN EXCLUDED.health_fetched_at IS NULL THEN repos.build_status ELSE EXCLUDED.build_status END,
+		health_fetched_at = COALESCE(EXCLUDED.health_fetched_at, repos.health_fetched_at)`
 
-	_, err := s.db.Exec(query,
-		repo.ID, repo.Name, repo.FullName, repo.OwnerLogin, repo.OwnerAvatar,
+	stack := repo.Stack
+	if stack == nil {
+		stack = []string{}
+	}
+	breakdown, err := breakdownJSON(repo.ScoreBreakdown)
+	if err != nil {
+		return err
+	}
+	var hasTests, hasCI *bool
+	var buildStatus *string
+	var healthFetchedAt *time.Time
+	if h := repo.Health; h != nil {
+		hasTests, hasCI, buildStatus, healthFetchedAt = &h.HasTests, &h.HasCI, &h.BuildStatus, &h.FetchedAt
+	}
+
+	_, err = s.db.Exec(query,
+		repo.SourceName(), repo.ID, repo.Name, repo.FullName, repo.OwnerLogin, repo.OwnerAvatar,
 		repo.HTMLURL, repo.Description, repo.Language, pq.Array(repo.Topics),
 		repo.Stargazers, repo.Forks, repo.PushedAt, repo.CreatedAt,
-		repo.IdeaScore, repo.Category, time.Now(),
+		repo.IdeaScore, repo.Category, time.Now(), repo.License,
+		repo.Archived, repo.OpenIssues, repo.DiskUsageKB, repo.LastCommitAt,
+		nullString(repo.LastCommitMessage), repo.LifecycleStatus(), repo.CheckedAt,
+		nullString(repo.Readme), nullString(repo.ReadmeExcerpt), pq.Array(repo.ReadmeImages),
+		repo.ReadmeFetchedAt, pq.Array(stack), repo.StackFetchedAt,
+		models.LicenseClass(repo.License), repo.ScoreVersion, breakdown, repo.RevivabilityScore,
+		hasTests, hasCI, buildStatus, healthFetchedAt, pq.Array(categoryIDs(repo.Categories, repo.Category)),
+		pq.Array(categoryConfidences(repo.Categories)), repo.CategoryVersion,
+		repo.DependenciesUpdatedAt,
 	)
 	return err
 }
 
+// upsertStatus keeps the status the revalidation job stored unless the repo
+// was checked at least as recently as that (CheckedAt). A search hit, which
+// has no CheckedAt, only shows that the repo exists and whether it is
+// archived, so it can only bring back a deleted repo or archive a fossil.
+var upsertStatus = fmt.Sprintf(`CASE
+			WHEN EXCLUDED.checked_at >= COALESCE(repos.checked_at, '-infinity') THEN EXCLUDED.status
+			WHEN EXCLUDED.checked_at IS NULL AND (repos.status = '%s'
+				OR repos.status = '%s' AND EXCLUDED.status = '%s') THEN EXCLUDED.status
+			ELSE repos.status
+		END`, models.StatusDeleted, models.StatusFossil, models.StatusArchived)
+
+// categoryIDs returns the ids of labels, or just primary for a repo that
+// hasn't been labelled.
+func categoryIDs(labels []models.CategoryLabel, primary string) []string {
+	if len(labels) == 0 {
+		return []string{primary}
+	}
+	ids := make([]string, len(labels))
+	for i, l := range labels {
+		ids[i] = l.ID
+	}
+	return ids
+}
+
+// categoryConfidences returns the confidences of labels, in order, or nil
+// for a repo that hasn't been labelled.
+func categoryConfidences(labels []models.CategoryLabel) []float64 {
+	if len(labels) == 0 {
+		return nil
+	}
+	c := make([]float64, len(labels))
+	for i, l := range labels {
+		c[i] = l.Confidence
+	}
+	return c
+}
+
+// breakdownJSON encodes a score breakdown for storage. A missing breakdown
+// is stored as NULL, which marks the repo for rescoring.
+func breakdownJSON(components []models.ScoreComponent) ([]byte, error) {
+	if len(components) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(components)
+	if err != nil {
+		return nil, fmt.Errorf("encoding score breakdown: %w", err)
+	}
+	return b, nil
+}
+
 func (s *RepoStore) UpsertBatch(repos []models.Repo) (int, error) {
 	count := 0
 	for _, repo := range repos {
 		if err := s.Upsert(repo); err != nil {
-			return count, fmt.Errorf("upserting repo %d: %w", repo.ID, err)
+			return count, fmt.Errorf("upserting %s repo %d: %w", repo.SourceName(), repo.ID, err)
 		}
 		count++
 	}
 	return count, nil
 }
 
-func (s *RepoStore) Query(category, sort, search string, page, perPage int) ([]models.Repo, int, error) {
+// ExistingIDs reports which of a source's ids are already stored.
+func (s *RepoStore) ExistingIDs(source string, ids []int64) (map[int64]bool, error) {
+	existing := make(map[int64]bool)
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	rows, err := s.db.Query("SELECT id FROM repos WHERE source = $1 AND id = ANY($2)", source, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("checking existing repos: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing[id] = true
+	}
+	return existing, rows.Err()
+}
+
+// RepoQuery holds the filters, sort and paging of a repo listing.
+type RepoQuery struct {
+	Categories    []string // matches repos in any of them; nil lists every category
+	Sort          string
+	Search        string
+	Status        string   // "" hides deleted and revived repos, "all" shows everything
+	Source        string   // "" lists every source
+	Stack         []string // repos must use every one of these
+	License       string   // a license class, or an SPDX id
+	RevivableOnly bool     // only repos whose license allows reuse
+	OwnerActive   *bool    // nil ignores owners; false matches enriched owners gone quiet
+	Breakdown     bool     // also load each repo's score breakdown
+	Page          int
+	PerPage       int
+
+	// Bounds on RevivabilityScore, inclusive; nil leaves that end unbounded.
+	MinRevivability *int
+	MaxRevivability *int
+
+	// CollapseClusters lists only the best matching repo of each idea
+	// cluster.
+	CollapseClusters bool
+}
+
+// repoColumns are the columns scanRepo reads. Columns are qualified so
+// they can be selected from joins with tables that share their names.
+const repoColumns = `repos.source, repos.id, repos.name, repos.full_name, repos.owner_login,
+	COALESCE(repos.owner_avatar, ''), repos.html_url, COALESCE(repos.description, ''),
+	COALESCE(repos.language, ''), repos.topics, repos.stargazers, repos.forks,
+	repos.pushed_at, repos.created_at, repos.idea_score, repos.score_version, repos.category,
+	repos.fetched_at, repos.license, COALESCE(repos.license_class, ''),
+	repos.archived, repos.open_issues, repos.disk_usage_kb, repos.last_commit_at,
+	COALESCE(repos.last_commit_message, ''), repos.status, repos.checked_at,
+	COALESCE(repos.readme_excerpt, ''), repos.readme_images,
+	repos.activity_start, repos.peak_week, COALESCE(repos.peak_commits, 0),
+	repos.months_peak_to_death, COALESCE(repos.contributors, 0), repos.stack,
+	repos.has_tests, repos.has_ci, COALESCE(repos.build_status, ''), repos.health_fetched_at,
+	COALESCE(repos.revivability_score, 0), repos.categories, repos.category_confidences,
+	repos.category_version, COALESCE(repos.cluster_id, 0), repos.cluster_size,
+	repos.dependencies_updated_at`
+
+// scanRepo scans a row of repoColumns, followed by any extra columns into
+// extra.
+func scanRepo(row interface{ Scan(...interface{}) error }, extra ...interface{}) (models.Repo, error) {
+	var r models.Repo
+	var license sql.NullString
+	var hasTests, hasCI sql.NullBool
+	var buildStatus string
+	var healthFetchedAt *time.Time
+	var categories []string
+	var confidences []float64
+	dest := []interface{}{
+		&r.Source, &r.ID, &r.Name, &r.FullName, &r.OwnerLogin, &r.OwnerAvatar,
+		&r.HTMLURL, &r.Description, &r.Language,
+		pq.Array(&r.Topics), &r.Stargazers, &r.Forks,
+		&r.PushedAt, &r.CreatedAt, &r.IdeaScore, &r.ScoreVersion, &r.Category, &r.FetchedAt,
+		&license, &r.LicenseClass, &r.Archived, &r.OpenIssues, &r.DiskUsageKB,
+		&r.LastCommitAt, &r.LastCommitMessage, &r.Status, &r.CheckedAt,
+		&r.ReadmeExcerpt, pq.Array(&r.ReadmeImages),
+		&r.ActivityStart, &r.PeakWeek, &r.PeakCommits,
+		&r.MonthsPeakToDeath, &r.Contributors, pq.Array(&r.Stack),
+		&hasTests, &hasCI, &buildStatus, &healthFetchedAt, &r.RevivabilityScore,
+		pq.Array(&categories), pq.Array(&confidences), &r.CategoryVersion,
+		&r.ClusterID, &r.ClusterSize, &r.DependenciesUpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	// A NULL license was never reported, which isn't the same as GitHub's
+	// NOASSERTION or no license at all, so writing the row back keeps it NULL.
+	if license.Valid {
+		r.License = &license.String
+	}
+	r.Derive(time.Now())
+	// Rows labelled before confidences were stored have none.
+	for i, id := range categories {
+		label := models.CategoryLabel{ID: id}
+		if i < len(confidences) {
+			label.Confidence = confidences[i]
+		}
+		r.Categories = append(r.Categories, label)
+	}
+	if healthFetchedAt != nil {
+		r.Health = &models.Health{
+			HasTests:    hasTests.Bool,
+			HasCI:       hasCI.Bool,
+			BuildStatus: buildStatus,
+			FetchedAt:   *healthFetchedAt,
+		}
+	}
+	return r, err
+}
+
+func (s *RepoStore) Query(rq RepoQuery) ([]models.Repo, int, error) {
+	page, perPage := rq.Page, rq.PerPage
 	if page < 1 {
 		page = 1
 	}
@@ -72,28 +295,100 @@ func (s *RepoStore) Query(category, sort, search string, page, perPage int) ([]m
 	var args []interface{}
 	argIdx := 1
 
-	if category != "" && category != "all" {
-		conditions = append(conditions, fmt.Sprintf("category = $%d", argIdx))
-		args = append(args, category)
+	if len(rq.Categories) > 0 {
+		conditions = append(conditions, fmt.Sprintf("categories && $%d", argIdx))
+		args = append(args, pq.Array(rq.Categories))
 		argIdx++
 	}
 
-	if search != "" {
+	if rq.Search != "" {
 		conditions = append(conditions, fmt.Sprintf(
 			"(LOWER(name) LIKE $%d OR LOWER(COALESCE(description, '')) LIKE $%d OR array_to_string(topics, ' ') ILIKE $%d)",
 			argIdx, argIdx, argIdx,
 		))
-		args = append(args, "%"+strings.ToLower(search)+"%")
+		args = append(args, "%"+strings.ToLower(rq.Search)+"%")
+		argIdx++
+	}
+
+	if rq.Source != "" {
+		conditions = append(conditions, fmt.Sprintf("repos.source = $%d", argIdx))
+		args = append(args, rq.Source)
 		argIdx++
 	}
 
+	if len(rq.Stack) > 0 {
+		conditions = append(conditions, fmt.Sprintf("stack @> $%d", argIdx))
+		args = append(args, pq.Array(rq.Stack))
+		argIdx++
+	}
+
+	if rq.License != "" {
+		column := "license_class"
+		if !isLicenseClass(rq.License) {
+			column = "LOWER(license)"
+		}
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, argIdx))
+		args = append(args, strings.ToLower(rq.License))
+		argIdx++
+	}
+
+	if rq.RevivableOnly {
+		conditions = append(conditions, revivableCondition)
+	}
+
+	// Unscored repos read as 0, as they are listed.
+	if rq.MinRevivability != nil {
+		conditions = append(conditions, fmt.Sprintf("COALESCE(revivability_score, 0) >= $%d", argIdx))
+		args = append(args, *rq.MinRevivability)
+		argIdx++
+	}
+	if rq.MaxRevivability != nil {
+		conditions = append(conditions, fmt.Sprintf("COALESCE(revivability_score, 0) <= $%d", argIdx))
+		args = append(args, *rq.MaxRevivability)
+		argIdx++
+	}
+
+	if rq.OwnerActive != nil {
+		// Enriched owners with no public activity, deleted accounts among
+		// them, are the least active of all.
+		condition := "owners.last_active_at >= $%d"
+		if !*rq.OwnerActive {
+			condition = "owners.enriched_at IS NOT NULL AND (owners.last_active_at IS NULL OR owners.last_active_at < $%d)"
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, argIdx))
+		args = append(args, time.Now().Add(-models.OwnerActiveWithin))
+		argIdx++
+	}
+
+	switch rq.Status {
+	case "":
+		conditions = append(conditions, hiddenStatusCondition)
+	case "all":
+	default:
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIdx))
+		args = append(args, rq.Status)
+		argIdx++
+	}
+
+	if rq.CollapseClusters {
+		// The same filters apply to the subquery's repos, so a cluster whose
+		// representative is filtered out is shown by its best repo that isn't.
+		inner := append([]string{"repos.cluster_id IS NOT NULL"}, conditions...)
+		conditions = append(conditions, fmt.Sprintf(`(repos.cluster_id IS NULL OR (repos.source, repos.id) IN (
+			SELECT DISTINCT ON (repos.cluster_id) repos.source, repos.id
+			FROM %s
+			WHERE %s
+			ORDER BY repos.cluster_id, %s))`,
+			reposWithOwners, strings.Join(inner, " AND "), clusterRank))
+	}
+
 	where := ""
 	if len(conditions) > 0 {
 		where = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Count total
-	countQuery := "SELECT COUNT(*) FROM repos " + where
+	countQuery := "SELECT COUNT(*) FROM " + reposWithOwners + " " + where
 	var total int
 	if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("counting repos: %w", err)
@@ -101,25 +396,41 @@ func (s *RepoStore) Query(category, sort, search string, page, perPage int) ([]m
 
 	// Sort
 	orderBy := "idea_score DESC"
-	switch sort {
+	switch rq.Sort {
 	case "latest":
 		orderBy = "created_at DESC"
 	case "stars":
 		orderBy = "stargazers DESC"
 	case "oldest":
 		orderBy = "pushed_at ASC"
+	case "recently_dead":
+		orderBy = "pushed_at DESC"
+	case "short_lived":
+		orderBy = lifespanMonths + " ASC"
+	case "long_lived":
+		orderBy = lifespanMonths + " DESC"
+	case "star_velocity":
+		orderBy = starsPerMonth + " DESC"
+	case "revivability":
+		orderBy = "revivability_score DESC NULLS LAST"
+	case "died_suddenly":
+		orderBy = "months_peak_to_death ASC NULLS LAST"
+	case "died_slowly":
+		orderBy = "months_peak_to_death DESC NULLS LAST"
+	}
+
+	columns := repoColumns + ", " + ownerColumns
+	if rq.Breakdown {
+		columns += ", score_breakdown"
 	}
 
 	offset := (page - 1) * perPage
 	selectQuery := fmt.Sprintf(`
-		SELECT id, name, full_name, owner_login, COALESCE(owner_avatar, ''),
-			html_url, COALESCE(description, ''), COALESCE(language, ''),
-			topics, stargazers, forks, pushed_at, created_at,
-			idea_score, category, fetched_at
-		FROM repos %s
+		SELECT %s
+		FROM %s %s
 		ORDER BY %s
 		LIMIT $%d OFFSET $%d`,
-		where, orderBy, argIdx, argIdx+1,
+		columns, reposWithOwners, where, orderBy, argIdx, argIdx+1,
 	)
 	args = append(args, perPage, offset)
 
@@ -130,18 +441,17 @@ func (s *RepoStore) Query(category, sort, search string, page, perPage int) ([]m
 	defer rows.Close()
 
 	var repos []models.Repo
+	now := time.Now()
 	for rows.Next() {
-		var r models.Repo
-		if err := rows.Scan(
-			&r.ID, &r.Name, &r.FullName, &r.OwnerLogin, &r.OwnerAvatar,
-			&r.HTMLURL, &r.Description, &r.Language,
-			pq.Array(&r.Topics), &r.Stargazers, &r.Forks,
-			&r.PushedAt, &r.CreatedAt, &r.IdeaScore, &r.Category, &r.FetchedAt,
-		); err != nil {
+		r, err := scanListedRepo(rows, rq.Breakdown, now)
+		if err != nil {
 			return nil, 0, fmt.Errorf("scanning repo: %w", err)
 		}
 		repos = append(repos, r)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading repos: %w", err)
+	}
 
 	if repos == nil {
 		repos = []models.Repo{}
@@ -150,23 +460,491 @@ func (s *RepoStore) Query(category, sort, search string, page, perPage int) ([]m
 	return repos, total, nil
 }
 
-func (s *RepoStore) Stats() (map[string]int, error) {
-	rows, err := s.db.Query("SELECT category, COUNT(*) FROM repos GROUP BY category")
+// Get returns one repo with its owner and score breakdown.
+func (s *RepoStore) Get(source string, id int64) (models.Repo, error) {
+	// Only the detail view charts weekly activity, so only it loads it.
+	var activityWeeks []int64
+	row := s.db.QueryRow(`
+		SELECT `+repoColumns+`, `+ownerColumns+`, score_breakdown, activity_weeks
+		FROM `+reposWithOwners+`
+		WHERE repos.source = $1 AND repos.id = $2`, source, id)
+	r, err := scanListedRepo(row, true, time.Now(), pq.Array(&activityWeeks))
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, ErrNotFound
+	}
+	if len(activityWeeks) > 0 {
+		r.ActivityWeeks = make([]int, len(activityWeeks))
+		for i, c := range activityWeeks {
+			r.ActivityWeeks[i] = int(c)
+		}
+	}
+	return r, err
+}
+
+// scanListedRepo scans a row of repoColumns and ownerColumns, followed by
+// score_breakdown when withBreakdown is set and then any extra columns into
+// extra.
+func scanListedRepo(row interface{ Scan(...interface{}) error }, withBreakdown bool, now time.Time, extra ...interface{}) (models.Repo, error) {
+	var login, ownerType sql.NullString
+	var staleRepos sql.NullInt64
+	var enrichedAt sql.NullTime
+	var owner models.Owner
+	var breakdown []byte
+	dest := []interface{}{&login, &ownerType, &owner.AccountCreatedAt, &owner.LastActiveAt, &staleRepos, &enrichedAt}
+	if withBreakdown {
+		dest = append(dest, &breakdown)
+	}
+	r, err := scanRepo(row, append(dest, extra...)...)
 	if err != nil {
-		return nil, err
+		return r, err
+	}
+	if login.Valid {
+		owner.Source, owner.Login, owner.Type = r.Source, login.String, ownerType.String
+		owner.StaleRepos = int(staleRepos.Int64)
+		owner.EnrichedAt = enrichedAt.Time
+		owner.Active = owner.IsActive(now)
+		r.Owner = &owner
+	}
+	if len(breakdown) > 0 {
+		if err := json.Unmarshal(breakdown, &r.ScoreBreakdown); err != nil {
+			return r, fmt.Errorf("decoding score breakdown: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// reposWithOwners joins each repo to its owner's enrichment, if any.
+const reposWithOwners = `repos LEFT JOIN owners
+	ON owners.source = repos.source AND owners.login = repos.owner_login`
+
+const ownerColumns = `owners.login, owners.owner_type, owners.account_created_at,
+	owners.last_active_at, owners.stale_repos, owners.enriched_at`
+
+// lifespanMonths and starsPerMonth compute Repo.LifespanMonths and
+// Repo.StarsPerMonth in SQL, for sorting. 2629746 is the seconds in an
+// average month.
+const (
+	lifespanMonths = "GREATEST(EXTRACT(EPOCH FROM pushed_at - created_at) / 2629746, 0)"
+	starsPerMonth  = "stargazers / GREATEST(" + lifespanMonths + ", 1)"
+)
+
+// hiddenStatusCondition keeps repos that are gone or active again out of
+// default listings.
+var hiddenStatusCondition = fmt.Sprintf("status NOT IN ('%s', '%s')", models.StatusDeleted, models.StatusRevived)
+
+// revivableCondition keeps repos whose code may be reused.
+var revivableCondition = fmt.Sprintf("license_class IN ('%s', '%s')", models.LicensePermissive, models.LicenseCopyleft)
+
+func isLicenseClass(s string) bool {
+	for _, class := range models.LicenseClasses {
+		if s == class {
+			return true
+		}
+	}
+	return false
+}
+
+// DueForRevalidation returns a source's repos never checked or last checked
+// before olderThan, least recently checked first. Deleted repos are not
+// rechecked.
+func (s *RepoStore) DueForRevalidation(source string, olderThan time.Time, limit int) ([]models.Repo, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoColumns+`
+		FROM repos
+		WHERE source = $1 AND status <> $2 AND (checked_at IS NULL OR checked_at < $3)
+		ORDER BY checked_at ASC NULLS FIRST, fetched_at ASC
+		LIMIT $4`, source, models.StatusDeleted, olderThan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos to revalidate: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []models.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// StoredReadmeExcerpts returns the README excerpt of each of ids whose README
+// has already been fetched, so ingestion only fetches READMEs it lacks.
+func (s *RepoStore) StoredReadmeExcerpts(source string, ids []int64) (map[int64]string, error) {
+	excerpts := make(map[int64]string)
+	if len(ids) == 0 {
+		return excerpts, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(readme_excerpt, '') FROM repos
+		WHERE source = $1 AND id = ANY($2) AND readme_fetched_at IS NOT NULL`, source, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("loading stored readmes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var excerpt string
+		if err := rows.Scan(&id, &excerpt); err != nil {
+			return nil, err
+		}
+		excerpts[id] = excerpt
+	}
+	return excerpts, rows.Err()
+}
+
+// StoredStack is what reading a repo's manifests stored: its stack and when
+// its dependencies were last updated.
+type StoredStack struct {
+	Stack                 []string
+	DependenciesUpdatedAt *time.Time
+}
+
+// StoredStacks returns the stack of each of ids whose manifests have already
+// been read, so ingestion only reads manifests for repos it hasn't seen.
+func (s *RepoStore) StoredStacks(source string, ids []int64) (map[int64]StoredStack, error) {
+	stacks := make(map[int64]StoredStack)
+	if len(ids) == 0 {
+		return stacks, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT id, stack, dependencies_updated_at FROM repos
+		WHERE source = $1 AND id = ANY($2) AND stack_fetched_at IS NOT NULL
+			AND dependencies_fetched_at IS NOT NULL`, source, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("loading stored stacks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var st StoredStack
+		if err := rows.Scan(&id, pq.Array(&st.Stack), &st.DependenciesUpdatedAt); err != nil {
+			return nil, err
+		}
+		stacks[id] = st
+	}
+	return stacks, rows.Err()
+}
+
+// MissingReadmes returns a source's repos whose README has never been fetched.
+func (s *RepoStore) MissingReadmes(source string, limit int) ([]models.Repo, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoColumns+`
+		FROM repos
+		WHERE source = $1 AND readme_fetched_at IS NULL AND status <> $2
+		ORDER BY idea_score DESC
+		LIMIT $3`, source, models.StatusDeleted, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos without readme: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []models.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// MissingStacks returns a source's repos whose manifests have never been
+// read, or were read before their history was. Repos whose last read failed
+// come after the rest, longest ago first, so they are retried without
+// holding up the others.
+func (s *RepoStore) MissingStacks(source string, limit int) ([]models.Repo, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoColumns+`
+		FROM repos
+		WHERE source = $1 AND (stack_fetched_at IS NULL OR dependencies_fetched_at IS NULL)
+			AND status <> $2
+		ORDER BY stack_attempted_at NULLS FIRST, idea_score DESC
+		LIMIT $3`, source, models.StatusDeleted, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos without stack: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []models.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// MarkStackFailed records that reading a repo's manifests failed, so
+// MissingStacks moves it to the back of the queue.
+func (s *RepoStore) MarkStackFailed(source string, id int64) error {
+	_, err := s.db.Exec("UPDATE repos SET stack_attempted_at = NOW() WHERE source = $1 AND id = $2", source, id)
+	return err
+}
+
+// MissingHealth returns a source's repos whose tests, CI and build status
+// haven't been looked at.
+func (s *RepoStore) MissingHealth(source string, limit int) ([]models.Repo, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoColumns+`
+		FROM repos
+		WHERE source = $1 AND health_fetched_at IS NULL AND status <> $2
+		ORDER BY idea_score DESC
+		LIMIT $3`, source, models.StatusDeleted, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos without health: %w", err)
 	}
 	defer rows.Close()
 
-	stats := make(map[string]int)
+	var repos []models.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// StoredHealth returns the stored health of those of a source's repos that
+// have been looked at.
+func (s *RepoStore) StoredHealth(source string, ids []int64) (map[int64]models.Health, error) {
+	health := make(map[int64]models.Health)
+	if len(ids) == 0 {
+		return health, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(has_tests, FALSE), COALESCE(has_ci, FALSE), COALESCE(build_status, ''),
+			health_fetched_at
+		FROM repos
+		WHERE source = $1 AND id = ANY($2) AND health_fetched_at IS NOT NULL`, source, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("loading stored health: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var h models.Health
+		if err := rows.Scan(&id, &h.HasTests, &h.HasCI, &h.BuildStatus, &h.FetchedAt); err != nil {
+			return nil, err
+		}
+		health[id] = h
+	}
+	return health, rows.Err()
+}
+
+// MissingActivity returns a source's repos whose commit activity hasn't been
+// fetched.
+func (s *RepoStore) MissingActivity(source string, limit int) ([]models.Repo, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoColumns+`
+		FROM repos
+		WHERE source = $1 AND activity_fetched_at IS NULL AND status <> $2
+		ORDER BY idea_score DESC
+		LIMIT $3`, source, models.StatusDeleted, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos without activity: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []models.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// Unevaluated returns repos whose idea score or categories weren't computed
+// with the given scorer and categorizer versions, that lack a stored score
+// breakdown or revivability score, or that were last evaluated before
+// evaluatedBefore.
+func (s *RepoStore) Unevaluated(scoreVersion, categoryVersion string, evaluatedBefore time.Time, limit int) ([]models.Repo, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoColumns+`
+		FROM repos
+		WHERE score_version <> $1 OR category_version <> $2
+			OR score_breakdown IS NULL OR revivability_score IS NULL
+			OR evaluated_at IS NULL OR evaluated_at < $3
+		ORDER BY source, id
+		LIMIT $4`, scoreVersion, categoryVersion, evaluatedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos to re-evaluate: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []models.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// SaveEvaluations stores what Repo.Evaluate derived for the repos (scores,
+// license class and categories) in one transaction, leaving everything else
+// untouched.
+func (s *RepoStore) SaveEvaluations(repos []models.Repo) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, r := range repos {
+		breakdown, err := breakdownJSON(r.ScoreBreakdown)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`UPDATE repos SET idea_score = $3, score_version = $4, score_breakdown = $5,
+				revivability_score = $6, license_class = $7, category = $8, categories = $9,
+				category_confidences = $10, category_version = $11, evaluated_at = NOW()
+			WHERE source = $1 AND id = $2`,
+			r.SourceName(), r.ID, r.IdeaScore, r.ScoreVersion, breakdown, r.RevivabilityScore,
+			r.LicenseClass, r.Category, pq.Array(categoryIDs(r.Categories, r.Category)),
+			pq.Array(categoryConfidences(r.Categories)), r.CategoryVersion,
+		); err != nil {
+			return fmt.Errorf("saving evaluation of %s repo %d: %w", r.SourceName(), r.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveActivity stores a repo's commit activity and the figures derived from it.
+func (s *RepoStore) SaveActivity(source string, id int64, a models.Activity) error {
+	var start, peak *time.Time
+	var monthsToDeath *float64
+	if len(a.Weeks) > 0 {
+		start, peak, monthsToDeath = &a.Start, &a.PeakWeek, &a.MonthsPeakToDeath
+	}
+	_, err := s.db.Exec(`
+		UPDATE repos SET
+			activity_start = $2, activity_weeks = $3, peak_week = $4, peak_commits = $5,
+			months_peak_to_death = $6, contributors = $7, activity_fetched_at = NOW()
+		WHERE id = $1 AND source = $8`,
+		id, start, pq.Array(a.Weeks), peak, a.PeakCommits, monthsToDeath, a.Contributors, source,
+	)
+	return err
+}
+
+// GetReadme returns a repo with its raw README loaded.
+func (s *RepoStore) GetReadme(source string, id int64) (models.Repo, error) {
+	var r models.Repo
+	err := s.db.QueryRow(`
+		SELECT source, id, html_url, COALESCE(readme, ''), COALESCE(readme_excerpt, ''),
+			readme_images, readme_fetched_at
+		FROM repos WHERE source = $1 AND id = $2`, source, id,
+	).Scan(&r.Source, &r.ID, &r.HTMLURL, &r.Readme, &r.ReadmeExcerpt, pq.Array(&r.ReadmeImages), &r.ReadmeFetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, ErrNotFound
+	}
+	return r, err
+}
+
+// RenameSource moves a source's repos, and their labels and owners, to a new
+// source name. It returns how many repos moved.
+func (s *RepoStore) RenameSource(from, to string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE repos SET source = $2 WHERE source = $1", from, to)
+	if err != nil {
+		return 0, fmt.Errorf("renaming repos: %w", err)
+	}
+	for _, table := range []string{"repo_labels", "owners", "owner_failures"} {
+		if _, err := tx.Exec("UPDATE "+table+" SET source = $2 WHERE source = $1", from, to); err != nil {
+			return 0, fmt.Errorf("renaming %s: %w", table, err)
+		}
+	}
+	n, _ := res.RowsAffected()
+	return n, tx.Commit()
+}
+
+// MarkChecked records a revalidation outcome without touching other fields.
+func (s *RepoStore) MarkChecked(source string, id int64, status string) error {
+	_, err := s.db.Exec("UPDATE repos SET status = $3, checked_at = NOW() WHERE source = $1 AND id = $2", source, id, status)
+	return err
+}
+
+// Stats counts listed repos in each of their categories, so a repo with
+// several categories counts towards each. total counts every repo once.
+func (s *RepoStore) Stats() (stats map[string]int, total int, err error) {
+	rows, err := s.db.Query(`
+		SELECT c, COUNT(*) FROM repos, unnest(categories) AS c
+		WHERE ` + hiddenStatusCondition + `
+		GROUP BY c`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	stats = make(map[string]int)
 	for rows.Next() {
 		var cat string
 		var count int
 		if err := rows.Scan(&cat, &count); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		stats[cat] = count
 	}
-	return stats, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM repos WHERE " + hiddenStatusCondition).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	return stats, total, nil
+}
+
+// Rollups counts listed repos in each group of categories, counting a repo
+// once per group however many of the group's categories it has.
+func (s *RepoStore) Rollups(groups map[string][]string) (map[string]int, error) {
+	var ids, members []string
+	for id, group := range groups {
+		for _, m := range group {
+			ids = append(ids, id)
+			members = append(members, m)
+		}
+	}
+	rows, err := s.db.Query(`
+		SELECT g.id, COUNT(DISTINCT (repos.source, repos.id))
+		FROM unnest($1::text[], $2::text[]) AS g(id, member)
+		JOIN repos ON g.member = ANY(repos.categories)
+		WHERE `+hiddenStatusCondition+`
+		GROUP BY g.id`,
+		pq.Array(ids), pq.Array(members))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
 }
 
 func (s *RepoStore) Count() (int, error) {
@@ -174,3 +952,8 @@ func (s *RepoStore) Count() (int, error) {
 	err := s.db.QueryRow("SELECT COUNT(*) FROM repos").Scan(&count)
 	return count, err
 }
+
+// nullString maps "" to NULL so optional text columns stay NULL rather than empty.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
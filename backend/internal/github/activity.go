@@ -0,0 +1,59 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type contributorStats struct {
+	Total int `json:"total"`
+	Weeks []struct {
+		Week    int64 `json:"w"`
+		Commits int   `json:"c"`
+	} `json:"weeks"`
+}
+
+// FetchCommitActivity returns a repo's commits per week across its whole
+// history (summed over its top 100 contributors) and the contributor count.
+// GitHub computes these statistics lazily: ready is false while it answers
+// 202, and the caller should try again later.
+func (c *Client) FetchCommitActivity(ctx context.Context, fullName string) (weekly map[int64]int, contributors int, ready bool, err error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/stats/contributors", c.apiURL, fullName)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.doCached(req)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer resp.Body.Close()
+
+	weekly = make(map[int64]int)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusAccepted:
+		return nil, 0, false, nil
+	case http.StatusNoContent:
+		// Empty repository.
+		return weekly, 0, true, nil
+	default:
+		return nil, 0, false, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var stats []contributorStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, 0, false, fmt.Errorf("decoding contributor stats: %w", err)
+	}
+	for _, s := range stats {
+		for _, w := range s.Weeks {
+			weekly[w.Week] += w.Commits
+		}
+	}
+	return weekly, len(stats), true, nil
+}
@@ -0,0 +1,191 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// appJWTLifetime stays under GitHub's ten minute maximum for app JWTs.
+	appJWTLifetime = 9 * time.Minute
+	// tokenRefreshMargin is how long before expiry an installation token is
+	// replaced, so no request goes out with a token about to lapse.
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// Credential supplies the bearer token for API requests. Each credential has
+// its own rate limit quota, which the client tracks separately.
+type Credential interface {
+	// Token returns a currently valid token.
+	Token(ctx context.Context) (string, error)
+	// ID names the credential in logs without revealing the secret.
+	ID() string
+}
+
+// StaticToken is a personal access token (or any other long-lived token).
+type StaticToken string
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t StaticToken) ID() string {
+	s := string(t)
+	if len(s) > 4 {
+		s = s[len(s)-4:]
+	}
+	return "token ..." + s
+}
+
+// AppCredential authenticates as a GitHub App installation. It signs a JWT
+// with the app's private key, exchanges it for an installation token and
+// replaces that token shortly before it expires.
+type AppCredential struct {
+	appID          string
+	installationID int64
+	key            *rsa.PrivateKey
+	apiURL         string
+	httpClient     *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppCredential builds an installation credential from the app's ID (or
+// client ID) and its PEM-encoded private key. apiURL is the API root the
+// token endpoint lives under; empty means api.github.com.
+func NewAppCredential(appID string, installationID int64, keyPEM []byte, apiURL string) (*AppCredential, error) {
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	return &AppCredential{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		apiURL:         strings.TrimRight(apiURL, "/"),
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (a *AppCredential) ID() string {
+	return fmt.Sprintf("app %s installation %d", a.appID, a.installationID)
+}
+
+// Token returns the cached installation token, minting a new one when it is
+// missing or within tokenRefreshMargin of expiry.
+func (a *AppCredential) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Until(a.expiresAt) > tokenRefreshMargin {
+		return a.token, nil
+	}
+
+	jwt, err := a.signJWT(time.Now())
+	if err != nil {
+		return "", fmt.Errorf("signing app JWT: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/app/installations/%d/access_tokens", a.apiURL, a.installationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("installation token endpoint returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding installation token: %w", err)
+	}
+	if result.Token == "" {
+		return "", errors.New("installation token endpoint returned no token")
+	}
+
+	a.token = result.Token
+	a.expiresAt = result.ExpiresAt
+	return a.token, nil
+}
+
+// signJWT builds the RS256 JWT GitHub expects from an app. iat is backdated
+// a minute to allow for clock drift.
+func (a *AppCredential) signJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": a.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+
+	sum := sha256.Sum256(buf.Bytes())
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(sig))
+	return buf.String(), nil
+}
+
+// parsePrivateKey accepts the PKCS#1 keys GitHub hands out as well as PKCS#8.
+func parsePrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("app private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("app private key is not an RSA key")
+	}
+	return key, nil
+}
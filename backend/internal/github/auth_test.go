@@ -0,0 +1,187 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeApp is a token endpoint for one app installation. It checks the app
+// JWT and hands out numbered tokens expiring after each of lifetimes in
+// turn, the last one repeating.
+type fakeApp struct {
+	t         *testing.T
+	key       *rsa.PublicKey
+	lifetimes []time.Duration
+
+	mu     sync.Mutex
+	minted int
+}
+
+func (f *fakeApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || r.URL.Path != "/app/installations/42/access_tokens" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := f.checkJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+		f.t.Errorf("bad app JWT: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	f.minted++
+	n := f.minted
+	lifetime := f.lifetimes[min(n, len(f.lifetimes))-1]
+	f.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":      fmt.Sprintf("ghs_installation%d", n),
+		"expires_at": time.Now().Add(lifetime).UTC().Format(time.RFC3339),
+	})
+}
+
+func (f *fakeApp) mints() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.minted
+}
+
+func (f *fakeApp) checkJWT(jwt string) error {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%d parts", len(parts))
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, sum[:], sig); err != nil {
+		return err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims.Iss != "1234" || claims.Exp-claims.Iat > 600 {
+		return fmt.Errorf("claims %+v", claims)
+	}
+	return nil
+}
+
+// newTestApp starts a fakeApp and returns it with a credential for it.
+func newTestApp(t *testing.T, lifetimes ...time.Duration) (*fakeApp, *AppCredential) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &fakeApp{t: t, key: &key.PublicKey, lifetimes: lifetimes}
+	srv := httptest.NewServer(app)
+	t.Cleanup(srv.Close)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	cred, err := NewAppCredential("1234", 42, keyPEM, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app, cred
+}
+
+// TestAppCredentialToken checks that an installation token is minted with a
+// valid app JWT, reused while it has time left and replaced once it is
+// within tokenRefreshMargin of expiry.
+func TestAppCredentialToken(t *testing.T) {
+	ctx := context.Background()
+	app, cred := newTestApp(t, 2*time.Minute, time.Hour)
+
+	steps := []struct {
+		want   string
+		minted int
+	}{
+		{"ghs_installation1", 1}, // first use mints
+		{"ghs_installation2", 2}, // the first expires within the margin
+		{"ghs_installation2", 2}, // the second has an hour left
+	}
+	for i, step := range steps {
+		token, err := cred.Token(ctx)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if minted := app.mints(); token != step.want || minted != step.minted {
+			t.Errorf("step %d: token %q after %d mints, want %q after %d", i, token, minted, step.want, step.minted)
+		}
+	}
+}
+
+// TestClientRotatesRateLimitedCredential checks that a request rate limited
+// on one credential is retried at once on another, and that later requests
+// go straight to the one with quota left.
+func TestClientRotatesRateLimitedCredential(t *testing.T) {
+	_, app := newTestApp(t, time.Hour)
+	reset := time.Now().Add(time.Hour).Unix()
+
+	var mu sync.Mutex
+	var used []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		mu.Lock()
+		used = append(used, token)
+		mu.Unlock()
+
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+		w.Header().Set("X-RateLimit-Resource", "core")
+		if token == "ghs_installation1" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"API rate limit exceeded for installation"}`)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		fmt.Fprint(w, `{"type":"User","created_at":"2015-04-01T00:00:00Z"}`)
+	}))
+	defer api.Close()
+
+	c := NewClient([]Credential{app, StaticToken("ghp_personal")}, WithBaseURL(api.URL))
+	for i := 0; i < 2; i++ {
+		var user struct {
+			Type string `json:"type"`
+		}
+		found, err := c.getJSON(context.Background(), api.URL+"/users/someone", &user)
+		if err != nil || !found || user.Type != "User" {
+			t.Fatalf("request %d: found %v, user %+v, err %v", i, found, user, err)
+		}
+	}
+
+	want := []string{"ghs_installation1", "ghp_personal", "ghp_personal"}
+	if strings.Join(used, ",") != strings.Join(want, ",") {
+		t.Errorf("tokens used %v, want %v", used, want)
+	}
+	if rl, ok := c.RateLimit("core"); !ok || rl.Limit != 10000 || rl.Remaining != 4999 {
+		t.Errorf("combined core limit %+v (known %v), want 4999 of 10000 left", rl, ok)
+	}
+}
@@ -0,0 +1,243 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a stored GitHub response plus the validators needed to
+// revalidate it with a conditional request.
+type CachedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+// ResponseCache persists responses between runs so unchanged pages can be
+// served from a 304, which does not count against the rate limit.
+type ResponseCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Put(key string, resp *CachedResponse) error
+}
+
+// FileCache is a ResponseCache that keeps one JSON file per response. Once
+// the entries take up more than maxBytes, the least recently used are
+// removed until they fit in nine tenths of it.
+type FileCache struct {
+	dir      string
+	maxBytes int64 // no limit if zero or less
+
+	mu   sync.Mutex
+	size int64 // bytes taken up by the entries
+}
+
+func NewFileCache(dir string, maxBytes int64) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache dir: %w", err)
+	}
+	fc := &FileCache{dir: dir, maxBytes: maxBytes}
+	entries, err := fc.entries()
+	if err != nil {
+		return nil, fmt.Errorf("reading cache dir: %w", err)
+	}
+	for _, e := range entries {
+		fc.size += e.Size()
+	}
+	return fc, nil
+}
+
+func (fc *FileCache) Get(key string) (*CachedResponse, bool) {
+	data, err := os.ReadFile(fc.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var cr CachedResponse
+	if err := json.Unmarshal(data, &cr); err != nil {
+		return nil, false
+	}
+	// The modification time doubles as the last use, for eviction.
+	now := time.Now()
+	os.Chtimes(fc.path(key), now, now)
+	return &cr, true
+}
+
+func (fc *FileCache) Put(key string, resp *CachedResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	// Write to a temp file and rename so readers never see a partial entry.
+	tmp, err := os.CreateTemp(fc.dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if old, err := os.Stat(fc.path(key)); err == nil {
+		fc.size -= old.Size()
+	}
+	if err := os.Rename(tmp.Name(), fc.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	fc.size += int64(len(data))
+	if fc.maxBytes > 0 && fc.size > fc.maxBytes {
+		return fc.evict(fc.maxBytes / 10 * 9)
+	}
+	return nil
+}
+
+// evict removes the least recently used entries until the rest take up at
+// most target bytes. fc.mu must be held.
+func (fc *FileCache) evict(target int64) error {
+	entries, err := fc.entries()
+	if err != nil {
+		return fmt.Errorf("reading cache dir: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime().Before(entries[j].ModTime()) })
+	fc.size = 0
+	for _, e := range entries {
+		fc.size += e.Size()
+	}
+	for _, e := range entries {
+		if fc.size <= target {
+			break
+		}
+		if err := os.Remove(filepath.Join(fc.dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fc.size -= e.Size()
+	}
+	return nil
+}
+
+// entries lists the cache's entry files.
+func (fc *FileCache) entries() ([]os.FileInfo, error) {
+	dirEntries, err := os.ReadDir(fc.dir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []os.FileInfo
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			continue
+		}
+		if info, err := d.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (fc *FileCache) path(key string) string {
+	return filepath.Join(fc.dir, key+".json")
+}
+
+// WithCache enables conditional requests backed by cache.
+func WithCache(cache ResponseCache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// doCached wraps do with ETag / Last-Modified revalidation for GET requests.
+// A 304 is turned back into the cached 200 so callers never see it.
+func (c *Client) doCached(req *http.Request) (*http.Response, error) {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return c.do(req)
+	}
+
+	key := cacheKey(req)
+	cached, ok := c.cache.Get(key)
+	if ok {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		resp.Body.Close()
+		return cached.toResponse(req), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := c.cache.Put(key, &CachedResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		ETag:         etag,
+		LastModified: lastModified,
+		StoredAt:     time.Now(),
+	}); err != nil {
+		// A failed cache write only costs us a revalidation next time.
+		log.Printf("Error caching GitHub response for %s: %v", req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (cr *CachedResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cr.StatusCode, http.StatusText(cr.StatusCode)),
+		StatusCode:    cr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cr.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(cr.Body)),
+		ContentLength: int64(len(cr.Body)),
+		Request:       req,
+	}
+}
+
+// cacheKey identifies a response by URL and representation. The token is
+// deliberately left out: we only read public data, so any credential may
+// revalidate an entry stored by another.
+func cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String() + " " + req.Header.Get("Accept")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDoCachedRevalidates checks that a cached 200 is revalidated with its
+// ETag and replayed when GitHub answers 304, including by a client that
+// finds the entry on disk after a restart.
+func TestDoCachedRevalidates(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string // If-None-Match of each request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, r.Header.Get("If-None-Match"))
+		mu.Unlock()
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"total_count":1}`)
+	}))
+	defer srv.Close()
+
+	var c *Client
+	dir := t.TempDir()
+	for i, restart := range []bool{true, false, true} {
+		if restart {
+			cache, err := NewFileCache(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c = NewClient(nil, WithBaseURL(srv.URL), WithCache(cache))
+		}
+		checkCachedGet(t, c, srv.URL, i)
+	}
+
+	if want := []string{"", `"v1"`, `"v1"`}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent If-None-Match %q, want %q", sent, want)
+	}
+}
+
+func checkCachedGet(t *testing.T, c *Client, base string, i int) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), "GET", base+"/search/repositories?q=x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.doCached(req)
+	if err != nil {
+		t.Fatalf("request %d: %v", i, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"total_count":1}` || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request %d: HTTP %d %q (%s), want the cached 200", i, resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestFileCacheRoundTrip(t *testing.T) {
+	fc, err := NewFileCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fc.Get("missing"); ok {
+		t.Error("Get found an entry never stored")
+	}
+	want := &CachedResponse{
+		StatusCode:   http.StatusOK,
+		Header:       http.Header{"Etag": {`W/"abc"`}, "Link": {`<https://api.github.com/x?page=2>; rel="next"`}},
+		Body:         []byte(`[{"id":1}]`),
+		ETag:         `W/"abc"`,
+		LastModified: "Fri, 14 Mar 2025 09:12:40 GMT",
+		StoredAt:     time.Date(2025, 3, 14, 9, 12, 40, 0, time.UTC),
+	}
+	if err := fc.Put("key", want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := fc.Get("key")
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("Get = %+v (found %v), want %+v", got, ok, want)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(fc.dir, "*.tmp")); len(matches) > 0 {
+		t.Errorf("temp files left behind: %q", matches)
+	}
+}
+
+// TestFileCacheEvicts checks that going over the size limit removes the
+// least recently used entries, counting entries found on disk at start.
+func TestFileCacheEvicts(t *testing.T) {
+	dir := t.TempDir()
+	entry := &CachedResponse{StatusCode: http.StatusOK, Body: []byte(strings.Repeat("x", 1000))}
+	fc, err := NewFileCache(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := fc.Put(key, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(fc.path("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a, b and c were used in that order, an hour apart.
+	for i, key := range []string{"a", "b", "c"} {
+		at := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(fc.path(key), at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Room for four and a half entries: a fifth brings it down to four,
+	// dropping b, as a was just used.
+	fc, err = NewFileCache(dir, 4*info.Size()+info.Size()/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fc.Get("a"); !ok {
+		t.Fatal("a not found")
+	}
+	for _, key := range []string{"d", "e"} {
+		if err := fc.Put(key, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true, "e": true} {
+		if _, err := os.Stat(fc.path(key)); (err == nil) != want {
+			t.Errorf("%s kept: %v, want %v", key, err == nil, want)
+		}
+	}
+	if fc.size != 4*info.Size() {
+		t.Errorf("size %d, want %d", fc.size, 4*info.Size())
+	}
+}
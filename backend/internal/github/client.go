@@ -1,18 +1,23 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ahmetburakdinc/codefossils/internal/models"
 )
 
-var searchQueries = []string{
+// defaultQueries seed the discovery_queries table on first start.
+var defaultQueries = []string{
 	"abandoned project",
 	"prototype NOT maintained",
 	"experiment NOT fork",
@@ -25,29 +30,157 @@ var searchQueries = []string{
 	"concept app",
 }
 
+const (
+	// queriesPerRun is how many discovery queries a sampled refresh runs.
+	queriesPerRun = 3
+	// samplePerPage is the page size of sampled searches.
+	samplePerPage = 30
+	// defaultConcurrency is how many sampled searches run at once.
+	defaultConcurrency = 3
+)
+
+// DefaultQueries returns the built-in discovery queries: repos untouched for
+// two years with more than five stars. Each has its own copy of the default
+// sorts, so decoding a request into one leaves the package's alone.
+func DefaultQueries() []models.DiscoveryQuery {
+	queries := make([]models.DiscoveryQuery, len(defaultQueries))
+	for i, q := range defaultQueries {
+		queries[i] = models.DiscoveryQuery{
+			Query:          q,
+			StaleAfterDays: 730,
+			MinStars:       6,
+			SortOptions:    append([]string(nil), sortOptions...),
+			Enabled:        true,
+		}
+	}
+	return queries
+}
+
 type Client struct {
-	token      string
-	httpClient *http.Client
+	name        string // source name repos are stored under
+	apiURL      string
+	graphQLURL  string
+	uploadURL   string
+	webURL      string // where repo pages and avatars live; unchanged by WithBaseURL
+	apiVersion  string // sent as X-GitHub-Api-Version
+	httpClient  *http.Client
+	rng         *rand.Rand       // used by FetchStaleRepos' sampling
+	now         func() time.Time // the date search cutoffs are computed from
+	concurrency int              // parallel searches in FetchStaleRepos
+	useGraphQL  bool
+	cache       ResponseCache
+
+	mu   sync.Mutex
+	pool []*pooledCredential
+
+	cursorMu sync.Mutex
+	cursors  map[cursorKey]string // GraphQL search cursors of pages seen, see cursorFor
+}
+
+// pooledCredential is one credential in the client's pool together with the
+// quotas GitHub last reported for it. cred is nil for anonymous access.
+type pooledCredential struct {
+	cred   Credential
+	limits map[string]RateLimit
 }
 
-func NewClient(token string) *Client {
-	return &Client{
-		token: token,
+// Option configures optional Client behaviour.
+type Option func(*Client)
+
+// WithGraphQL makes FetchStaleRepos use the GraphQL v4 search, which returns
+// license, archive state, issue counts, last commit and README in one query
+// per page. GraphQL requires a token. Its search pages by cursor, so reaching
+// a page the client hasn't been near costs a cheap request per page before
+// it, as for a sampled page past the first or a crawl resumed after a
+// restart.
+func WithGraphQL() Option {
+	return func(c *Client) {
+		c.useGraphQL = true
+	}
+}
+
+// WithBaseURL sends every REST and GraphQL request to baseURL instead of
+// https://api.github.com, e.g. a local fake server. Unlike WithEndpoints it
+// doesn't change the source name repos are stored under.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.apiURL = strings.TrimRight(baseURL, "/")
+		c.graphQLURL = c.apiURL + "/graphql"
+	}
+}
+
+// WithTransport replaces the HTTP transport, e.g. with a RecordingTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = rt
+	}
+}
+
+// WithSeed makes FetchStaleRepos' random choice of queries, sorts and pages
+// reproducible, so a recorded refresh can be replayed request for request.
+func WithSeed(seed int64) Option {
+	return func(c *Client) {
+		c.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithDate pins the date that "pushed before" cutoffs are computed from, so
+// replayed searches match the recorded ones on any day.
+func WithDate(date time.Time) Option {
+	return func(c *Client) {
+		c.now = func() time.Time { return date }
+	}
+}
+
+// WithConcurrency sets how many of FetchStaleRepos' searches run at once.
+func WithConcurrency(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// NewClient creates a client that spreads requests over creds, always using
+// the one with the most quota left. With no credentials it calls the API
+// anonymously.
+func NewClient(creds []Credential, opts ...Option) *Client {
+	c := &Client{
+		name:       models.SourceGitHub,
+		apiURL:     defaultAPIURL,
+		graphQLURL: defaultGraphQLURL,
+		uploadURL:  defaultUploadURL,
+		webURL:     defaultWebURL,
+		apiVersion: DefaultAPIVersion,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:         time.Now,
+		concurrency: defaultConcurrency,
+	}
+	for _, cred := range creds {
+		c.pool = append(c.pool, &pooledCredential{cred: cred, limits: make(map[string]RateLimit)})
 	}
+	if len(c.pool) == 0 {
+		c.pool = []*pooledCredential{{limits: make(map[string]RateLimit)}}
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type searchResponse struct {
-	Items []ghRepo `json:"items"`
+	TotalCount int      `json:"total_count"`
+	Items      []ghRepo `json:"items"`
 }
 
 type ghRepo struct {
-	ID    int64 `json:"id"`
-	Name  string `json:"name"`
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
 	FullName string `json:"full_name"`
-	Owner struct {
+	Owner    struct {
 		Login     string `json:"login"`
 		AvatarURL string `json:"avatar_url"`
 	} `json:"owner"`
@@ -59,114 +192,270 @@ type ghRepo struct {
 	ForksCount  int      `json:"forks_count"`
 	PushedAt    string   `json:"pushed_at"`
 	CreatedAt   string   `json:"created_at"`
+	Archived    bool     `json:"archived"`
+	OpenIssues  int      `json:"open_issues_count"`
+	Size        int      `json:"size"`
+	License     *struct {
+		SPDXID string `json:"spdx_id"`
+	} `json:"license"`
 }
 
 var sortOptions = []string{"stars", "updated", "best-match"}
 
-func (c *Client) FetchStaleRepos() ([]models.Repo, error) {
-	twoYearsAgo := time.Now().AddDate(-2, 0, 0).Format("2006-01-02")
+// Name identifies the GitHub deployment as a repo source: github for
+// github.com, ghes for GitHub Enterprise Server.
+func (c *Client) Name() string {
+	return c.name
+}
 
+// FetchStaleRepos runs a random sample of the given queries, each on a random
+// page with one of its sort options, and returns the results per query. The
+// searches run concurrently on up to c.concurrency workers. On a rate limit
+// or when ctx is done the remaining searches are abandoned and the results
+// gathered so far are returned along with the error.
+func (c *Client) FetchStaleRepos(ctx context.Context, queries []models.DiscoveryQuery) ([]models.QueryResult, error) {
 	// Pick 3 random queries
-	shuffled := make([]string, len(searchQueries))
-	copy(shuffled, searchQueries)
-	rand.Shuffle(len(shuffled), func(i, j int) {
+	shuffled := make([]models.DiscoveryQuery, len(queries))
+	copy(shuffled, queries)
+	c.rng.Shuffle(len(shuffled), func(i, j int) {
 		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 	})
-	queries := shuffled[:3]
-
-	seen := make(map[int64]bool)
-	var allRepos []models.Repo
-
-	for _, q := range queries {
-		sortBy := sortOptions[rand.Intn(len(sortOptions))]
-		page := rand.Intn(10) + 1 // random page 1-10
-
-		searchQ := fmt.Sprintf("%s pushed:<%s stars:>5", q, twoYearsAgo)
-		apiURL := fmt.Sprintf("https://api.github.com/search/repositories?q=%s&sort=%s&order=desc&per_page=30&page=%d",
-			url.QueryEscape(searchQ), sortBy, page)
+	if len(shuffled) > queriesPerRun {
+		shuffled = shuffled[:queriesPerRun]
+	}
 
-		req, err := http.NewRequest("GET", apiURL, nil)
-		if err != nil {
-			log.Printf("Error creating request for query %q: %v", q, err)
-			continue
+	// Draw every random choice up front, in order, so a seeded client makes
+	// the same requests however the workers get scheduled.
+	picks := make([]samplePick, len(shuffled))
+	for i, q := range shuffled {
+		sorts := q.SortOptions
+		if len(sorts) == 0 {
+			sorts = sortOptions
 		}
-
-		req.Header.Set("Accept", "application/vnd.github.mercy-preview+json")
-		if c.token != "" {
-			req.Header.Set("Authorization", "Bearer "+c.token)
+		picks[i] = samplePick{
+			query:  q,
+			sortBy: sorts[c.rng.Intn(len(sorts))],
+			page:   c.rng.Intn(10) + 1, // random page 1-10
 		}
+	}
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			log.Printf("Error fetching query %q: %v", q, err)
-			continue
-		}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		if resp.StatusCode == 403 {
-			resp.Body.Close()
-			return allRepos, fmt.Errorf("GitHub API rate limit hit (403)")
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range picks {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(c.concurrency, len(picks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				p := &picks[i]
+				p.repos, _, p.err = c.search(ctx, searchQualifiers(p.query, c.now()), p.sortBy, p.page, samplePerPage)
+				p.done = true
+				var rlErr *RateLimitError
+				if errors.As(p.err, &rlErr) {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
 
-		if resp.StatusCode != 200 {
-			resp.Body.Close()
-			log.Printf("GitHub API returned %d for query %q", resp.StatusCode, q)
+	// Merge in pick order so duplicates are attributed the same way on every
+	// run, whichever search finished first.
+	seen := make(map[int64]bool)
+	var results []models.QueryResult
+	var runErr error
+	total := 0
+
+	for _, p := range picks {
+		if !p.done {
 			continue
 		}
-
-		var result searchResponse
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			resp.Body.Close()
-			log.Printf("Error decoding response for query %q: %v", q, err)
+		if p.err != nil {
+			var rlErr *RateLimitError
+			switch {
+			case errors.As(p.err, &rlErr):
+				runErr = p.err
+			case ctx.Err() != nil:
+				// Abandoned because of a rate limit elsewhere or the caller's
+				// cancellation; reported below.
+			default:
+				log.Printf("Error fetching query %q: %v", p.query.Query, p.err)
+			}
 			continue
 		}
-		resp.Body.Close()
 
-		for _, item := range result.Items {
-			if seen[item.ID] {
+		result := models.QueryResult{Query: p.query}
+		for _, repo := range p.repos {
+			if seen[repo.ID] {
+				result.Duplicates++
 				continue
 			}
-			seen[item.ID] = true
+			seen[repo.ID] = true
+			result.Repos = append(result.Repos, repo)
+		}
+		results = append(results, result)
+		total += len(result.Repos)
 
-			desc := ""
-			if item.Description != nil {
-				desc = *item.Description
-			}
-			lang := ""
-			if item.Language != nil {
-				lang = *item.Language
-			}
+		log.Printf("Fetched %d repos for query %q (sort=%s, page=%d)", len(p.repos), p.query.Query, p.sortBy, p.page)
+	}
+	if runErr == nil {
+		// The deferred cancel hasn't run yet, so any error here is the caller's.
+		runErr = ctx.Err()
+	}
 
-			topics := item.Topics
-			if topics == nil {
-				topics = []string{}
-			}
+	log.Printf("Total unique repos fetched: %d", total)
+	return results, runErr
+}
 
-			pushedAt, _ := time.Parse(time.RFC3339, item.PushedAt)
-			createdAt, _ := time.Parse(time.RFC3339, item.CreatedAt)
-
-			repo := models.Repo{
-				ID:          item.ID,
-				Name:        item.Name,
-				FullName:    item.FullName,
-				OwnerLogin:  item.Owner.Login,
-				OwnerAvatar: item.Owner.AvatarURL,
-				HTMLURL:     item.HTMLURL,
-				Description: desc,
-				Language:    lang,
-				Topics:      topics,
-				Stargazers:  item.StarCount,
-				Forks:       item.ForksCount,
-				PushedAt:    pushedAt,
-				CreatedAt:   createdAt,
-				IdeaScore:   models.ComputeIdeaScore(item.StarCount, item.ForksCount, desc, topics),
-				Category:    models.CategorizeRepo(item.Name, desc, topics, lang),
-			}
-			allRepos = append(allRepos, repo)
-		}
+// samplePick is one search of a sampled refresh and, once run, its outcome.
+type samplePick struct {
+	query  models.DiscoveryQuery
+	sortBy string
+	page   int
+
+	done  bool
+	repos []models.Repo
+	err   error
+}
+
+// searchQualifiers turns a discovery query into a GitHub search string.
+func searchQualifiers(q models.DiscoveryQuery, now time.Time) string {
+	cutoff := now.AddDate(0, 0, -q.StaleAfterDays).Format("2006-01-02")
+	return fmt.Sprintf("%s pushed:<%s stars:>=%d", q.Query, cutoff, q.MinStars)
+}
+
+// search fetches one page of results and the total match count, through
+// GraphQL or REST depending on the client's configuration.
+func (c *Client) search(ctx context.Context, searchQ, sortBy string, page, perPage int) ([]models.Repo, int, error) {
+	if c.useGraphQL {
+		return c.searchGraphQL(ctx, searchQ, sortBy, page, perPage)
+	}
+	return c.searchREST(ctx, searchQ, sortBy, page, perPage)
+}
+
+func (c *Client) searchREST(ctx context.Context, searchQ, sortBy string, page, perPage int) ([]models.Repo, int, error) {
+	apiURL := fmt.Sprintf("%s/search/repositories?q=%s&sort=%s&order=desc&per_page=%d&page=%d",
+		c.apiURL, url.QueryEscape(searchQ), sortBy, perPage, page)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.doCached(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, 0, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var result searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("decoding response: %w", err)
+	}
+
+	repos := make([]models.Repo, 0, len(result.Items))
+	for _, item := range result.Items {
+		repos = append(repos, c.webURLs(item.toModel(c.name)))
+	}
+	return repos, result.TotalCount, nil
+}
+
+func (item ghRepo) toModel(source string) models.Repo {
+	desc := ""
+	if item.Description != nil {
+		desc = *item.Description
+	}
+	lang := ""
+	if item.Language != nil {
+		lang = *item.Language
+	}
+	license := ""
+	if item.License != nil {
+		license = item.License.SPDXID
+	}
 
-		log.Printf("Fetched %d repos for query %q (sort=%s, page=%d)", len(result.Items), q, sortBy, page)
+	topics := item.Topics
+	if topics == nil {
+		topics = []string{}
 	}
 
-	log.Printf("Total unique repos fetched: %d", len(allRepos))
-	return allRepos, nil
+	pushedAt, _ := time.Parse(time.RFC3339, item.PushedAt)
+	createdAt, _ := time.Parse(time.RFC3339, item.CreatedAt)
+
+	return models.Repo{
+		Source:      source,
+		ID:          item.ID,
+		Name:        item.Name,
+		FullName:    item.FullName,
+		OwnerLogin:  item.Owner.Login,
+		OwnerAvatar: item.Owner.AvatarURL,
+		HTMLURL:     item.HTMLURL,
+		Description: desc,
+		Language:    lang,
+		Topics:      topics,
+		Stargazers:  item.StarCount,
+		Forks:       item.ForksCount,
+		PushedAt:    pushedAt,
+		CreatedAt:   createdAt,
+		License:     &license,
+		Archived:    item.Archived,
+		OpenIssues:  item.OpenIssues,
+		DiskUsageKB: item.Size,
+	}
+}
+
+// FetchRepoByID looks a repository up by its numeric ID, which survives
+// renames and transfers. found is false when GitHub no longer serves the
+// repo: deleted (404, 410) or taken down (451). A 403 that isn't a rate
+// limit, such as SAML enforcement or a token without access, is an error:
+// it says nothing about whether the repo still exists.
+func (c *Client) FetchRepoByID(ctx context.Context, id int64) (repo models.Repo, found bool, err error) {
+	apiURL := fmt.Sprintf("%s/repositories/%d", c.apiURL, id)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return repo, false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.doCached(req)
+	if err != nil {
+		return repo, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone, http.StatusUnavailableForLegalReasons:
+		return repo, false, nil
+	case http.StatusForbidden:
+		return repo, false, fmt.Errorf("GitHub API denied access to repo %d (403)", id)
+	default:
+		return repo, false, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var item ghRepo
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return repo, false, fmt.Errorf("decoding response: %w", err)
+	}
+	return c.webURLs(item.toModel(c.name)), true, nil
 }
@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// replayQueries are the discovery queries testdata/replay answers. Its
+// fixtures are written by hand in the recorder's format, dated as if
+// recorded on recorded_on.txt's date, not captured from api.github.com.
+func replayQueries() []models.DiscoveryQuery {
+	var queries []models.DiscoveryQuery
+	for _, q := range []string{"hackathon project", "weekend project", "proof of concept"} {
+		queries = append(queries, models.DiscoveryQuery{
+			Query:          q,
+			StaleAfterDays: 730,
+			MinStars:       6,
+			SortOptions:    []string{"stars", "updated"},
+			Enabled:        true,
+		})
+	}
+	return queries
+}
+
+// TestFetchStaleReposReplay runs a sampled refresh against the replay
+// fixtures: the seed and fixture date make it send the requests they answer,
+// and a repo found by two queries counts once.
+func TestFetchStaleReposReplay(t *testing.T) {
+	const dir = "testdata/replay"
+	date, err := FixtureDate(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(ReplayHandler(dir))
+	defer srv.Close()
+
+	c := NewClient(nil, WithBaseURL(srv.URL), WithSeed(1), WithDate(date))
+	results, err := c.FetchStaleRepos(context.Background(), replayQueries())
+	if err != nil {
+		t.Fatalf("FetchStaleRepos: %v", err)
+	}
+
+	got := make(map[string][]string)
+	duplicates := 0
+	for _, r := range results {
+		names := []string{}
+		for _, repo := range r.Repos {
+			if repo.Source != models.SourceGitHub || repo.PushedAt.IsZero() || repo.License == nil || *repo.License != "MIT" {
+				t.Errorf("%s: incomplete repo %+v", repo.FullName, repo)
+			}
+			names = append(names, repo.FullName)
+		}
+		got[r.Query.Query] = names
+		duplicates += r.Duplicates
+	}
+	if len(got) != 3 {
+		t.Fatalf("got results for %d queries, want 3: %v", len(got), got)
+	}
+	if n := len(got["hackathon project"]) + len(got["weekend project"]); n != 3 || duplicates != 1 {
+		t.Errorf("overlapping queries returned %d repos and %d duplicates, want 3 and 1: %v", n, duplicates, got)
+	}
+	if want := []string{"oduya/p2p-notes"}; len(got["proof of concept"]) != 1 || got["proof of concept"][0] != want[0] {
+		t.Errorf("proof of concept = %v, want %v", got["proof of concept"], want)
+	}
+}
@@ -0,0 +1,214 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+const (
+	crawlPerPage    = 100
+	searchResultCap = 1000 // GitHub never returns more than this per search
+	dateLayout      = "2006-01-02"
+)
+
+// crawlEpoch is the earliest date a window can start at; nothing on GitHub
+// predates it.
+var crawlEpoch = time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// CursorStore persists crawl windows between runs.
+type CursorStore interface {
+	Windows(queryID int64) ([]models.CrawlWindow, error)
+	SaveWindow(w *models.CrawlWindow) error
+	SplitWindow(parent models.CrawlWindow, children []models.CrawlWindow) error
+	ResetWindows(queryID int64) error
+}
+
+// Crawl walks every query exhaustively instead of sampling it. Each query is
+// cut into pushed/created date windows small enough to stay under the 1000
+// result cap, and every page of every window is fetched in order. At most
+// pageBudget search requests are made per call; the cursors let the next
+// call resume where this one stopped, including after ctx is cancelled.
+func (c *Client) Crawl(ctx context.Context, queries []models.DiscoveryQuery, cursors CursorStore, pageBudget int) ([]models.QueryResult, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	// Spread the budget so one huge query can't starve the others.
+	share := pageBudget / len(queries)
+	if share < 1 {
+		share = 1
+	}
+
+	seen := make(map[int64]bool)
+	var results []models.QueryResult
+	total := 0
+
+	for _, q := range queries {
+		if pageBudget <= 0 {
+			break
+		}
+		result, used, err := c.crawlQuery(ctx, q, cursors, min(share, pageBudget), seen)
+		pageBudget -= used
+		if len(result.Repos) > 0 || result.Duplicates > 0 {
+			results = append(results, result)
+			total += len(result.Repos)
+		}
+		if err != nil {
+			var rlErr *RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				return results, err
+			}
+			log.Printf("Error crawling query %q: %v", q.Query, err)
+		}
+	}
+
+	log.Printf("Crawl fetched %d unique repos", total)
+	return results, nil
+}
+
+// crawlQuery advances one query's windows by up to budget pages and returns
+// the number of search requests it made.
+func (c *Client) crawlQuery(ctx context.Context, q models.DiscoveryQuery, cursors CursorStore, budget int, seen map[int64]bool) (models.QueryResult, int, error) {
+	result := models.QueryResult{Query: q}
+
+	windows, err := cursors.Windows(q.ID)
+	if err != nil {
+		return result, 0, err
+	}
+	if allDone(windows) {
+		// First run, or the previous pass finished: start a new pass.
+		if len(windows) > 0 {
+			log.Printf("Crawl of query %q complete, starting a new pass", q.Query)
+			if err := cursors.ResetWindows(q.ID); err != nil {
+				return result, 0, err
+			}
+		}
+		root := rootWindow(q, c.now())
+		if err := cursors.SaveWindow(&root); err != nil {
+			return result, 0, err
+		}
+		windows = []models.CrawlWindow{root}
+	}
+
+	used := 0
+	for i := 0; i < len(windows) && used < budget; i++ {
+		w := windows[i]
+		for !w.Done && used < budget {
+			repos, total, err := c.search(ctx, windowQuery(q, w), "stars", w.NextPage, crawlPerPage)
+			used++
+			if err != nil {
+				return result, used, err
+			}
+
+			if total > searchResultCap && w.NextPage == 1 {
+				if children := splitWindow(w); children != nil {
+					if err := cursors.SplitWindow(w, children); err != nil {
+						return result, used, fmt.Errorf("splitting window: %w", err)
+					}
+					// Crawl the halves next, before moving on.
+					rest := append(children, windows[i+1:]...)
+					windows = append(windows[:i+1], rest...)
+					break
+				}
+				log.Printf("Window %s of query %q matches %d repos; only the first %d are reachable",
+					windowQualifiers(w), q.Query, total, searchResultCap)
+			}
+
+			for _, repo := range repos {
+				if seen[repo.ID] {
+					result.Duplicates++
+					continue
+				}
+				seen[repo.ID] = true
+				result.Repos = append(result.Repos, repo)
+			}
+
+			w.TotalCount = total
+			w.NextPage++
+			if len(repos) == 0 || (w.NextPage-1)*crawlPerPage >= min(total, searchResultCap) {
+				w.Done = true
+			}
+			if err := cursors.SaveWindow(&w); err != nil {
+				return result, used, fmt.Errorf("saving cursor: %w", err)
+			}
+		}
+	}
+
+	log.Printf("Crawled %d pages for query %q (%d new this run)", used, q.Query, len(result.Repos))
+	return result, used, nil
+}
+
+func rootWindow(q models.DiscoveryQuery, now time.Time) models.CrawlWindow {
+	now = now.UTC()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, -q.StaleAfterDays-1)
+	return models.CrawlWindow{
+		QueryID:     q.ID,
+		PushedFrom:  crawlEpoch,
+		PushedTo:    cutoff,
+		CreatedFrom: crawlEpoch,
+		CreatedTo:   cutoff,
+		NextPage:    1,
+		TotalCount:  -1,
+	}
+}
+
+// splitWindow halves the pushed range, or the created range once pushed is
+// down to a single day. It returns nil when the window can't shrink further.
+func splitWindow(w models.CrawlWindow) []models.CrawlWindow {
+	a, b := w, w
+	switch {
+	case w.PushedTo.After(w.PushedFrom):
+		mid := midpoint(w.PushedFrom, w.PushedTo)
+		a.PushedTo = mid
+		b.PushedFrom = mid.AddDate(0, 0, 1)
+		// A repo can't be created after its last push.
+		if a.CreatedTo.After(a.PushedTo) {
+			a.CreatedTo = a.PushedTo
+		}
+	case w.CreatedTo.After(w.CreatedFrom):
+		mid := midpoint(w.CreatedFrom, w.CreatedTo)
+		a.CreatedTo = mid
+		b.CreatedFrom = mid.AddDate(0, 0, 1)
+	default:
+		return nil
+	}
+
+	children := []models.CrawlWindow{a, b}
+	for i := range children {
+		children[i].ID = 0
+		children[i].NextPage = 1
+		children[i].TotalCount = -1
+		children[i].Done = false
+	}
+	return children
+}
+
+func midpoint(from, to time.Time) time.Time {
+	days := int(to.Sub(from).Hours() / 24)
+	return from.AddDate(0, 0, days/2)
+}
+
+func allDone(windows []models.CrawlWindow) bool {
+	for _, w := range windows {
+		if !w.Done {
+			return false
+		}
+	}
+	return true
+}
+
+func windowQuery(q models.DiscoveryQuery, w models.CrawlWindow) string {
+	return fmt.Sprintf("%s %s stars:>=%d", q.Query, windowQualifiers(w), q.MinStars)
+}
+
+func windowQualifiers(w models.CrawlWindow) string {
+	return fmt.Sprintf("pushed:%s..%s created:%s..%s",
+		w.PushedFrom.Format(dateLayout), w.PushedTo.Format(dateLayout),
+		w.CreatedFrom.Format(dateLayout), w.CreatedTo.Format(dateLayout))
+}
@@ -0,0 +1,291 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestMidpoint(t *testing.T) {
+	tests := []struct{ from, to, want string }{
+		{"2015-01-01", "2015-01-01", "2015-01-01"},
+		{"2015-01-01", "2015-01-02", "2015-01-01"},
+		{"2015-01-01", "2015-01-03", "2015-01-02"},
+		{"2015-01-01", "2015-01-31", "2015-01-16"},
+		{"2015-02-20", "2015-03-10", "2015-03-01"},
+		{"2008-01-01", "2024-03-12", "2016-02-05"},
+	}
+	for _, tt := range tests {
+		if got := midpoint(day(tt.from), day(tt.to)); !got.Equal(day(tt.want)) {
+			t.Errorf("midpoint(%s, %s) = %s, want %s", tt.from, tt.to, got.Format(dateLayout), tt.want)
+		}
+	}
+}
+
+func TestSplitWindow(t *testing.T) {
+	window := func(pushedFrom, pushedTo, createdFrom, createdTo string) models.CrawlWindow {
+		return models.CrawlWindow{
+			PushedFrom: day(pushedFrom), PushedTo: day(pushedTo),
+			CreatedFrom: day(createdFrom), CreatedTo: day(createdTo),
+			NextPage: 1, TotalCount: -1,
+		}
+	}
+	tests := []struct {
+		name   string
+		parent models.CrawlWindow
+		want   []models.CrawlWindow
+	}{
+		{
+			"pushed range first, created clipped to the earlier half's pushes",
+			window("2015-01-01", "2015-01-10", "2008-01-01", "2015-01-10"),
+			[]models.CrawlWindow{
+				window("2015-01-01", "2015-01-05", "2008-01-01", "2015-01-05"),
+				window("2015-01-06", "2015-01-10", "2008-01-01", "2015-01-10"),
+			},
+		},
+		{
+			"two pushed days",
+			window("2015-01-01", "2015-01-02", "2014-01-01", "2014-12-31"),
+			[]models.CrawlWindow{
+				window("2015-01-01", "2015-01-01", "2014-01-01", "2014-12-31"),
+				window("2015-01-02", "2015-01-02", "2014-01-01", "2014-12-31"),
+			},
+		},
+		{
+			"one pushed day splits the created range",
+			window("2019-06-01", "2019-06-01", "2010-01-01", "2010-01-04"),
+			[]models.CrawlWindow{
+				window("2019-06-01", "2019-06-01", "2010-01-01", "2010-01-02"),
+				window("2019-06-01", "2019-06-01", "2010-01-03", "2010-01-04"),
+			},
+		},
+		{
+			"two created days",
+			window("2019-06-01", "2019-06-01", "2010-01-01", "2010-01-02"),
+			[]models.CrawlWindow{
+				window("2019-06-01", "2019-06-01", "2010-01-01", "2010-01-01"),
+				window("2019-06-01", "2019-06-01", "2010-01-02", "2010-01-02"),
+			},
+		},
+		{
+			"one day of each can't be split",
+			window("2020-02-02", "2020-02-02", "2020-02-02", "2020-02-02"),
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		parent := tt.parent
+		// A parent part way through is split into fresh windows.
+		parent.ID, parent.QueryID, parent.NextPage, parent.TotalCount = 7, 3, 4, 5000
+		for i := range tt.want {
+			tt.want[i].QueryID = 3
+		}
+		if got := splitWindow(parent); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: splitWindow =\n%v\nwant\n%v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// memCursors is a CursorStore in memory, in the database's crawl order.
+type memCursors struct {
+	mu      sync.Mutex
+	windows map[int64]models.CrawlWindow
+	nextID  int64
+}
+
+func (m *memCursors) Windows(queryID int64) ([]models.CrawlWindow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.CrawlWindow
+	for _, w := range m.windows {
+		if w.QueryID == queryID {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].PushedFrom.Equal(out[j].PushedFrom) {
+			return out[i].PushedFrom.Before(out[j].PushedFrom)
+		}
+		return out[i].CreatedFrom.Before(out[j].CreatedFrom)
+	})
+	return out, nil
+}
+
+func (m *memCursors) SaveWindow(w *models.CrawlWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w.ID == 0 {
+		m.nextID++
+		w.ID = m.nextID
+	}
+	m.windows[w.ID] = *w
+	return nil
+}
+
+func (m *memCursors) SplitWindow(parent models.CrawlWindow, children []models.CrawlWindow) error {
+	m.mu.Lock()
+	delete(m.windows, parent.ID)
+	m.mu.Unlock()
+	for i := range children {
+		if err := m.SaveWindow(&children[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memCursors) ResetWindows(queryID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, w := range m.windows {
+		if w.QueryID == queryID {
+			delete(m.windows, id)
+		}
+	}
+	return nil
+}
+
+// fakeRepo is a search result of fakeSearch.
+type fakeRepo struct {
+	id              int64
+	pushed, created time.Time
+}
+
+// fakeSearch serves repo searches over repos, filtered by the pushed and
+// created ranges crawl windows ask for and capped at searchResultCap
+// reachable results like GitHub's.
+func fakeSearch(t *testing.T, repos []fakeRepo) *httptest.Server {
+	rangesRe := regexp.MustCompile(`pushed:(\S+)\.\.(\S+) created:(\S+)\.\.(\S+)`)
+	in := func(d time.Time, from, to string) bool {
+		return !d.Before(day(from)) && !d.After(day(to))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := rangesRe.FindStringSubmatch(r.URL.Query().Get("q"))
+		if r.URL.Path != "/search/repositories" || m == nil {
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+
+		var matched []fakeRepo
+		for _, repo := range repos {
+			if in(repo.pushed, m[1], m[2]) && in(repo.created, m[3], m[4]) {
+				matched = append(matched, repo)
+			}
+		}
+		if (page-1)*perPage >= searchResultCap {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"message":"Only the first 1000 search results are available"}`)
+			return
+		}
+		resp := map[string]interface{}{"total_count": len(matched)}
+		items := []map[string]interface{}{}
+		for _, repo := range matched[min((page-1)*perPage, len(matched)):min(page*perPage, len(matched))] {
+			items = append(items, map[string]interface{}{
+				"id": repo.id, "name": fmt.Sprint("repo", repo.id), "full_name": fmt.Sprint("someone/repo", repo.id),
+				"pushed_at": repo.pushed.Format(time.RFC3339), "created_at": repo.created.Format(time.RFC3339),
+			})
+		}
+		resp["items"] = items
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// crawlFixture returns repos that make a crawl split windows every way it
+// can: 1500 spread over a year of pushes, 1200 pushed on one day but
+// created over years, and 1050 pushed and created on one day, of which only
+// 1000 can be reached. It also returns the ids a crawl should find.
+func crawlFixture() ([]fakeRepo, map[int64]bool) {
+	var repos []fakeRepo
+	reachable := make(map[int64]bool)
+	add := func(pushed, created time.Time, reach bool) {
+		id := int64(len(repos) + 1)
+		repos = append(repos, fakeRepo{id: id, pushed: pushed, created: created})
+		if reach {
+			reachable[id] = true
+		}
+	}
+	for i := 0; i < 1500; i++ {
+		pushed := day("2015-01-01").AddDate(0, 0, i%365)
+		add(pushed, pushed.AddDate(-1, 0, -i%300), true)
+	}
+	for i := 0; i < 1200; i++ {
+		add(day("2019-06-01"), day("2010-01-01").AddDate(0, 0, i*2), true)
+	}
+	for i := 0; i < 1050; i++ {
+		add(day("2020-02-02"), day("2020-02-02"), i < searchResultCap)
+	}
+	return repos, reachable
+}
+
+// TestCrawlSplitsAndResumes crawls crawlFixture in one go and in small
+// steps that resume from the stored cursors, and checks that both reach
+// every reachable repo exactly once.
+func TestCrawlSplitsAndResumes(t *testing.T) {
+	repos, reachable := crawlFixture()
+	srv := fakeSearch(t, repos)
+	q := models.DiscoveryQuery{ID: 1, Query: "language:go", MinStars: 10, StaleAfterDays: 365}
+	c := NewClient(nil, WithBaseURL(srv.URL), WithDate(day("2025-03-14")))
+
+	for _, budget := range []int{5000, 7} {
+		cursors := &memCursors{windows: make(map[int64]models.CrawlWindow)}
+		found := make(map[int64]int)
+		for run := 0; ; run++ {
+			if run > 1000 {
+				t.Fatalf("budget %d: crawl not finished after %d runs", budget, run)
+			}
+			results, err := c.Crawl(context.Background(), []models.DiscoveryQuery{q}, cursors, budget)
+			if err != nil {
+				t.Fatalf("budget %d, run %d: %v", budget, run, err)
+			}
+			for _, res := range results {
+				for _, r := range res.Repos {
+					found[r.ID]++
+				}
+			}
+			windows, _ := cursors.Windows(q.ID)
+			if allDone(windows) {
+				break
+			}
+		}
+
+		for id, n := range found {
+			if n > 1 || !reachable[id] {
+				t.Errorf("budget %d: repo %d found %d times (reachable: %v)", budget, id, n, reachable[id])
+			}
+		}
+		if len(found) != len(reachable) {
+			t.Errorf("budget %d: found %d repos, want %d", budget, len(found), len(reachable))
+		}
+
+		// Only windows that can't be split are left over the cap.
+		windows, _ := cursors.Windows(q.ID)
+		for _, w := range windows {
+			if w.TotalCount > searchResultCap && splitWindow(w) != nil {
+				t.Errorf("budget %d: window %s holds %d repos unsplit", budget, windowQualifiers(w), w.TotalCount)
+			}
+		}
+	}
+}
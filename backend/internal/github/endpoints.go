@@ -0,0 +1,135 @@
+package github
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+const (
+	defaultAPIURL     = "https://api.github.com"
+	defaultGraphQLURL = "https://api.github.com/graphql"
+	defaultUploadURL  = "https://uploads.github.com"
+	defaultWebURL     = "https://github.com"
+	// DefaultAPIVersion is the REST API version requests ask for.
+	DefaultAPIVersion = "2022-11-28"
+)
+
+// Endpoints locate the APIs of a GitHub deployment: github.com or a GitHub
+// Enterprise Server instance.
+type Endpoints struct {
+	API     string // REST root, e.g. https://github.example.com/api/v3
+	GraphQL string
+	Upload  string // release asset uploads; not used by discovery
+}
+
+// ResolveEndpoints fills in the GraphQL and upload URLs that weren't given,
+// following each deployment's layout: api.github.com/graphql and
+// uploads.github.com on github.com, <host>/api/graphql and <host>/api/uploads
+// on Enterprise Server. An empty apiURL means github.com.
+func ResolveEndpoints(apiURL, graphQLURL, uploadURL string) Endpoints {
+	e := Endpoints{
+		API:     strings.TrimRight(apiURL, "/"),
+		GraphQL: strings.TrimRight(graphQLURL, "/"),
+		Upload:  strings.TrimRight(uploadURL, "/"),
+	}
+	if e.API == "" {
+		e.API = defaultAPIURL
+	}
+
+	var graphQL, upload string
+	switch {
+	case !e.Enterprise():
+		graphQL, upload = defaultGraphQLURL, defaultUploadURL
+	case strings.HasSuffix(e.API, "/api/v3"):
+		server := strings.TrimSuffix(e.API, "/api/v3")
+		graphQL, upload = server+"/api/graphql", server+"/api/uploads"
+	default:
+		graphQL, upload = e.API+"/graphql", e.API+"/uploads"
+	}
+	if e.GraphQL == "" {
+		e.GraphQL = graphQL
+	}
+	if e.Upload == "" {
+		e.Upload = upload
+	}
+	return e
+}
+
+// Enterprise reports whether the endpoints belong to a GitHub Enterprise
+// Server instance rather than github.com. The API host is compared without
+// case or port, so https://api.github.com:443 is still github.com.
+func (e Endpoints) Enterprise() bool {
+	u, err := url.Parse(e.API)
+	return err == nil && !strings.EqualFold(u.Hostname(), "api.github.com")
+}
+
+// Web returns the root repo pages and avatars are served under:
+// https://github.com, or the Enterprise Server's host.
+func (e Endpoints) Web() string {
+	if !e.Enterprise() {
+		return defaultWebURL
+	}
+	if strings.HasSuffix(e.API, "/api/v3") {
+		return strings.TrimSuffix(e.API, "/api/v3")
+	}
+	u, err := url.Parse(e.API)
+	if err != nil {
+		return e.API
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// WithEndpoints points the client at a GitHub deployment. Repos from an
+// Enterprise Server are stored under the ghes source, so their IDs never
+// collide with github.com's.
+func WithEndpoints(e Endpoints) Option {
+	return func(c *Client) {
+		c.apiURL, c.graphQLURL, c.uploadURL = e.API, e.GraphQL, e.Upload
+		c.webURL = e.Web()
+		if e.Enterprise() {
+			c.name = models.SourceGitHubEnterprise
+		}
+	}
+}
+
+// WithAPIVersion sets the X-GitHub-Api-Version header; empty omits it, for
+// Enterprise Server releases that predate API versioning.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// webURLs makes a repo's page and owner avatar absolute on the deployment's
+// web host, as Enterprise Server can return them relative to it. An owner
+// without an avatar URL gets the <host>/<login>.png one, which every
+// deployment serves.
+func (c *Client) webURLs(repo models.Repo) models.Repo {
+	base, err := url.Parse(c.webURL + "/")
+	if err != nil {
+		return repo
+	}
+	resolve := func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil || u.IsAbs() {
+			return raw
+		}
+		return base.ResolveReference(u).String()
+	}
+	if repo.HTMLURL == "" {
+		repo.HTMLURL = c.webURL + "/" + repo.FullName
+	}
+	repo.HTMLURL = resolve(repo.HTMLURL)
+	if repo.OwnerAvatar == "" && repo.OwnerLogin != "" {
+		repo.OwnerAvatar = c.webURL + "/" + url.PathEscape(repo.OwnerLogin) + ".png"
+	}
+	repo.OwnerAvatar = resolve(repo.OwnerAvatar)
+	return repo
+}
+
+// Endpoints returns the URLs the client talks to.
+func (c *Client) Endpoints() Endpoints {
+	return Endpoints{API: c.apiURL, GraphQL: c.graphQLURL, Upload: c.uploadURL}
+}
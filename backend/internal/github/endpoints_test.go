@@ -0,0 +1,60 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+func TestResolveEndpoints(t *testing.T) {
+	tests := []struct {
+		api        string
+		enterprise bool
+		graphQL    string
+		web        string
+	}{
+		{"", false, "https://api.github.com/graphql", "https://github.com"},
+		{"https://API.github.com:443/", false, "https://api.github.com/graphql", "https://github.com"},
+		{"https://github.example.com/api/v3", true, "https://github.example.com/api/graphql", "https://github.example.com"},
+		{"https://ghe.internal:8443/api/v3/", true, "https://ghe.internal:8443/api/graphql", "https://ghe.internal:8443"},
+		{"https://api.ghe.example.com", true, "https://api.ghe.example.com/graphql", "https://api.ghe.example.com"},
+	}
+	for _, tt := range tests {
+		e := ResolveEndpoints(tt.api, "", "")
+		if e.Enterprise() != tt.enterprise || e.GraphQL != tt.graphQL || e.Web() != tt.web {
+			t.Errorf("%q: enterprise %v, GraphQL %q, web %q; want %v, %q, %q",
+				tt.api, e.Enterprise(), e.GraphQL, e.Web(), tt.enterprise, tt.graphQL, tt.web)
+		}
+	}
+}
+
+func TestWebURLs(t *testing.T) {
+	c := NewClient(nil, WithEndpoints(ResolveEndpoints("https://github.example.com/api/v3", "", "")))
+	if c.Name() != models.SourceGitHubEnterprise {
+		t.Fatalf("source %q, want %q", c.Name(), models.SourceGitHubEnterprise)
+	}
+
+	tests := []struct {
+		in           models.Repo
+		html, avatar string
+	}{
+		{
+			models.Repo{FullName: "infra/deployer", OwnerLogin: "infra", HTMLURL: "https://github.example.com/infra/deployer", OwnerAvatar: "https://github.example.com/avatars/u/12?"},
+			"https://github.example.com/infra/deployer", "https://github.example.com/avatars/u/12?",
+		},
+		{
+			models.Repo{FullName: "infra/deployer", OwnerLogin: "infra", HTMLURL: "/infra/deployer", OwnerAvatar: "/avatars/u/12?"},
+			"https://github.example.com/infra/deployer", "https://github.example.com/avatars/u/12?",
+		},
+		{
+			models.Repo{FullName: "infra/deployer", OwnerLogin: "infra"},
+			"https://github.example.com/infra/deployer", "https://github.example.com/infra.png",
+		},
+	}
+	for _, tt := range tests {
+		got := c.webURLs(tt.in)
+		if got.HTMLURL != tt.html || got.OwnerAvatar != tt.avatar {
+			t.Errorf("webURLs(%q, %q) = %q, %q; want %q, %q", tt.in.HTMLURL, tt.in.OwnerAvatar, got.HTMLURL, got.OwnerAvatar, tt.html, tt.avatar)
+		}
+	}
+}
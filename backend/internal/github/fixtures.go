@@ -0,0 +1,239 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Fixture is one recorded request/response pair. Fixtures are stored one per
+// file, named after the request so they can be found again when replayed
+// against a different host.
+type Fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Accept     string      `json:"accept,omitempty"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"` // used instead of Body for non-UTF-8 bodies
+}
+
+// RecordingTransport passes requests through to the real API and saves every
+// response as a fixture in dir. Credentials are never written to disk.
+type RecordingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// fixtureDateFile holds the date a fixture directory was recorded on.
+const fixtureDateFile = "recorded_on.txt"
+
+// NewRecordingTransport records into dir, sending requests through next
+// (http.DefaultTransport when nil).
+func NewRecordingTransport(dir string, next http.RoundTripper) (*RecordingTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating fixture directory: %w", err)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RecordingTransport{dir: dir, next: next}, nil
+}
+
+// FixtureDate returns the date dir was recorded on; searches replay only if
+// they are built from the same date (see WithDate). A directory recorded
+// into for the first time is stamped with today's date.
+func FixtureDate(dir string, record bool) (time.Time, error) {
+	path := filepath.Join(dir, fixtureDateFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && record {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		if err := os.WriteFile(path, []byte(today.Format(dateLayout)+"\n"), 0o644); err != nil {
+			return today, fmt.Errorf("stamping fixture directory: %w", err)
+		}
+		return today, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading fixture date: %w", err)
+	}
+	return time.Parse(dateLayout, strings.TrimSpace(string(data)))
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// A 304 only means "same as your cache"; keep the full response instead.
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	f := Fixture{
+		Method:     req.Method,
+		URL:        req.URL.RequestURI(),
+		Accept:     req.Header.Get("Accept"),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+	}
+	if utf8.Valid(body) {
+		f.Body = string(body)
+	} else {
+		f.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	if err := t.save(fixtureName(req, reqBody), &f); err != nil {
+		log.Printf("Error recording fixture for %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (t *RecordingTransport) save(name string, f *Fixture) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(t.dir, name)
+	tmp, err := os.CreateTemp(t.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	// CreateTemp makes the file private; fixtures are meant to be committed.
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReplayHandler is a fake GitHub API that answers every request with the
+// fixture recorded for it, or 404 when there is none.
+func ReplayHandler(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := readRequestBody(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		name := fixtureName(r, body)
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			log.Printf("Replay: no fixture for %s %s (%s)", r.Method, r.URL.RequestURI(), name)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"message":"no fixture recorded for this request"}`)
+			return
+		}
+
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			http.Error(w, fmt.Sprintf("corrupt fixture %s: %v", name, err), http.StatusInternalServerError)
+			return
+		}
+		respBody := []byte(f.Body)
+		if f.BodyBase64 != "" {
+			if respBody, err = base64.StdEncoding.DecodeString(f.BodyBase64); err != nil {
+				http.Error(w, fmt.Sprintf("corrupt fixture %s: %v", name, err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		for k, vals := range f.Header {
+			// The body is re-sent as recorded, uncompressed and unchunked.
+			switch http.CanonicalHeaderKey(k) {
+			case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+				continue
+			}
+			w.Header()[k] = vals
+		}
+		w.WriteHeader(f.StatusCode)
+		w.Write(respBody)
+	})
+}
+
+// StartReplayServer serves the fixtures in dir on a local port and returns
+// the base URL to point a Client at (see WithBaseURL).
+func StartReplayServer(dir string) (string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("opening fixture directory: %w", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("starting replay server: %w", err)
+	}
+	go func() {
+		if err := http.Serve(ln, ReplayHandler(dir)); err != nil {
+			log.Printf("Replay server stopped: %v", err)
+		}
+	}()
+	return "http://" + ln.Addr().String(), nil
+}
+
+var unsafeNameRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// fixtureName identifies a request by method, path, query, Accept header and
+// body, but not by host or credentials, so recordings made against
+// api.github.com replay against a local server. The readable prefix is only
+// there for humans browsing the directory.
+func fixtureName(req *http.Request, body []byte) string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s %s?%s\n%s\n", req.Method, req.URL.Path, req.URL.Query().Encode(), req.Header.Get("Accept"))
+	sum.Write(body)
+
+	prefix := strings.Trim(unsafeNameRe.ReplaceAllString(req.URL.Path, "_"), "_")
+	if len(prefix) > 60 {
+		prefix = prefix[:60]
+	}
+	return fmt.Sprintf("%s_%s_%s.json", req.Method, prefix, hex.EncodeToString(sum.Sum(nil))[:16])
+}
+
+// readRequestBody reads and restores a request body so it can be hashed.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
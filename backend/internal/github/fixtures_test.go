@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRecordingTransportReplays checks that fixtures are recorded without
+// credentials, readable by others so they can be committed, and replayed
+// for the same request against another host.
+func TestRecordingTransportReplays(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		fmt.Fprintf(w, `{"total_count":1,"items":[{"full_name":"someone/app","q":%q}]}`, r.URL.Query().Get("q"))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	rt, err := NewRecordingTransport(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetch := func(client *http.Client, base string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), "GET", base+"/search/repositories?q=weekend+project", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer ghp_secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	_, recorded := fetch(&http.Client{Transport: rt}, upstream.URL)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 {
+		t.Fatalf("recorded %q, want one fixture", files)
+	}
+	info, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o644 {
+		t.Errorf("fixture mode %v, want 0644", mode)
+	}
+	if data, _ := os.ReadFile(files[0]); strings.Contains(string(data), "ghp_secret") {
+		t.Error("fixture holds the credential")
+	}
+
+	replay := httptest.NewServer(ReplayHandler(dir))
+	defer replay.Close()
+	if status, body := fetch(http.DefaultClient, replay.URL); status != http.StatusOK || body != recorded {
+		t.Errorf("replayed HTTP %d %q, want %q", status, body, recorded)
+	}
+}
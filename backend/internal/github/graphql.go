@@ -0,0 +1,326 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+const searchReposQuery = `
+query($q: String!, $first: Int!, $after: String) {
+  search(query: $q, type: REPOSITORY, first: $first, after: $after) {
+    repositoryCount
+    pageInfo { endCursor hasNextPage }
+    nodes {
+      ... on Repository {
+        databaseId
+        name
+        nameWithOwner
+        url
+        description
+        owner { login avatarUrl }
+        primaryLanguage { name }
+        repositoryTopics(first: 20) { nodes { topic { name } } }
+        stargazerCount
+        forkCount
+        pushedAt
+        createdAt
+        isArchived
+        diskUsage
+        licenseInfo { spdxId }
+        issues(states: OPEN) { totalCount }
+        defaultBranchRef {
+          target { ... on Commit { committedDate messageHeadline } }
+        }
+        readmeUpper: object(expression: "HEAD:README.md") { ... on Blob { text } }
+        readmeLower: object(expression: "HEAD:readme.md") { ... on Blob { text } }
+      }
+    }
+  }
+}`
+
+// searchCursorQuery pages through a search without fetching the results,
+// for the cursor of a later page.
+const searchCursorQuery = `
+query($q: String!, $first: Int!, $after: String) {
+  search(query: $q, type: REPOSITORY, first: $first, after: $after) {
+    repositoryCount
+    pageInfo { endCursor hasNextPage }
+  }
+}`
+
+// maxCursors bounds how many search cursors a client remembers.
+const maxCursors = 10000
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLSearchResponse struct {
+	Data struct {
+		Search struct {
+			RepositoryCount int `json:"repositoryCount"`
+			PageInfo        struct {
+				EndCursor   string `json:"endCursor"`
+				HasNextPage bool   `json:"hasNextPage"`
+			} `json:"pageInfo"`
+			Nodes []gqlRepo `json:"nodes"`
+		} `json:"search"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// err returns the response's errors, unless it has results anyway.
+func (r *graphQLSearchResponse) err() error {
+	if len(r.Errors) == 0 || len(r.Data.Search.Nodes) > 0 {
+		return nil
+	}
+	msgs := make([]string, len(r.Errors))
+	for i, e := range r.Errors {
+		msgs[i] = e.Message
+	}
+	return fmt.Errorf("GraphQL errors: %s", strings.Join(msgs, "; "))
+}
+
+// cursorKey identifies a page of a GraphQL search.
+type cursorKey struct {
+	query         string
+	page, perPage int
+}
+
+type gqlBlob struct {
+	Text string `json:"text"`
+}
+
+type gqlRepo struct {
+	DatabaseID    int64   `json:"databaseId"`
+	Name          string  `json:"name"`
+	NameWithOwner string  `json:"nameWithOwner"`
+	URL           string  `json:"url"`
+	Description   *string `json:"description"`
+	Owner         struct {
+		Login     string `json:"login"`
+		AvatarURL string `json:"avatarUrl"`
+	} `json:"owner"`
+	PrimaryLanguage *struct {
+		Name string `json:"name"`
+	} `json:"primaryLanguage"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+	StargazerCount int       `json:"stargazerCount"`
+	ForkCount      int       `json:"forkCount"`
+	PushedAt       time.Time `json:"pushedAt"`
+	CreatedAt      time.Time `json:"createdAt"`
+	IsArchived     bool      `json:"isArchived"`
+	DiskUsage      int       `json:"diskUsage"`
+	LicenseInfo    *struct {
+		SPDXID string `json:"spdxId"`
+	} `json:"licenseInfo"`
+	Issues struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"issues"`
+	DefaultBranchRef *struct {
+		Target struct {
+			CommittedDate   time.Time `json:"committedDate"`
+			MessageHeadline string    `json:"messageHeadline"`
+		} `json:"target"`
+	} `json:"defaultBranchRef"`
+	ReadmeUpper *gqlBlob `json:"readmeUpper"`
+	ReadmeLower *gqlBlob `json:"readmeLower"`
+}
+
+// searchGraphQL runs one page of a repository search through the GraphQL v4
+// API. GraphQL search is cursor-based; see cursorFor for how it gets to a
+// page.
+func (c *Client) searchGraphQL(ctx context.Context, searchQ, sortBy string, page, perPage int) ([]models.Repo, int, error) {
+	if !c.authenticated() {
+		return nil, 0, fmt.Errorf("GraphQL search requires a GitHub token")
+	}
+
+	if sortBy != "best-match" {
+		searchQ += " sort:" + sortBy + "-desc"
+	}
+	after, total, ok, err := c.cursorFor(ctx, searchQ, page, perPage)
+	if err != nil || !ok {
+		return nil, total, err
+	}
+
+	var result graphQLSearchResponse
+	if err := c.postGraphQL(ctx, searchReposQuery, searchVars(searchQ, perPage, after), &result); err != nil {
+		return nil, 0, err
+	}
+	if err := result.err(); err != nil {
+		return nil, 0, err
+	}
+	if info := result.Data.Search.PageInfo; info.HasNextPage {
+		c.rememberCursor(cursorKey{searchQ, page + 1, perPage}, info.EndCursor)
+	}
+
+	repos := make([]models.Repo, 0, len(result.Data.Search.Nodes))
+	for _, node := range result.Data.Search.Nodes {
+		// Non-repository nodes decode as empty objects.
+		if node.DatabaseID == 0 {
+			continue
+		}
+		repos = append(repos, c.webURLs(node.toModel(c.name)))
+	}
+	return repos, result.Data.Search.RepositoryCount, nil
+}
+
+// cursorFor returns the cursor to ask for a page of a search with, "" for
+// the first. GitHub's cursors are opaque, so a page is only reached through
+// the endCursor of the one before it: the client remembers the cursors of
+// the pages it has seen, and walks from the closest of them, asking for
+// nothing but the cursor of each page in between. ok is false, with the
+// search's total, when it has fewer pages.
+func (c *Client) cursorFor(ctx context.Context, searchQ string, page, perPage int) (after string, total int, ok bool, err error) {
+	from := 1
+	c.cursorMu.Lock()
+	for p := page; p > 1; p-- {
+		if cursor, known := c.cursors[cursorKey{searchQ, p, perPage}]; known {
+			from, after = p, cursor
+			break
+		}
+	}
+	c.cursorMu.Unlock()
+
+	for p := from; p < page; p++ {
+		var result graphQLSearchResponse
+		if err := c.postGraphQL(ctx, searchCursorQuery, searchVars(searchQ, perPage, after), &result); err != nil {
+			return "", 0, false, err
+		}
+		if err := result.err(); err != nil {
+			return "", 0, false, err
+		}
+		info := result.Data.Search.PageInfo
+		if !info.HasNextPage {
+			return "", result.Data.Search.RepositoryCount, false, nil
+		}
+		after = info.EndCursor
+		c.rememberCursor(cursorKey{searchQ, p + 1, perPage}, after)
+	}
+	return after, 0, true, nil
+}
+
+// rememberCursor stores the cursor of a search page for cursorFor. Once
+// maxCursors are stored they are all forgotten, which only costs walking
+// to a page again.
+func (c *Client) rememberCursor(key cursorKey, cursor string) {
+	c.cursorMu.Lock()
+	defer c.cursorMu.Unlock()
+	if c.cursors == nil || len(c.cursors) >= maxCursors {
+		c.cursors = make(map[cursorKey]string)
+	}
+	c.cursors[key] = cursor
+}
+
+func searchVars(searchQ string, perPage int, after string) map[string]interface{} {
+	vars := map[string]interface{}{
+		"q":     searchQ,
+		"first": perPage,
+	}
+	if after != "" {
+		vars["after"] = after
+	}
+	return vars
+}
+
+// postGraphQL sends a GraphQL query and decodes the response into v.
+func (c *Client) postGraphQL(ctx context.Context, query string, vars map[string]interface{}, v interface{}) error {
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: vars})
+	if err != nil {
+		return fmt.Errorf("encoding GraphQL request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphQLURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("GitHub GraphQL API returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding GraphQL response: %w", err)
+	}
+	return nil
+}
+
+func (node gqlRepo) toModel(source string) models.Repo {
+	desc := ""
+	if node.Description != nil {
+		desc = *node.Description
+	}
+	lang := ""
+	if node.PrimaryLanguage != nil {
+		lang = node.PrimaryLanguage.Name
+	}
+	license := ""
+	if node.LicenseInfo != nil {
+		license = node.LicenseInfo.SPDXID
+	}
+
+	topics := make([]string, 0, len(node.RepositoryTopics.Nodes))
+	for _, t := range node.RepositoryTopics.Nodes {
+		topics = append(topics, t.Topic.Name)
+	}
+
+	repo := models.Repo{
+		Source:      source,
+		ID:          node.DatabaseID,
+		Name:        node.Name,
+		FullName:    node.NameWithOwner,
+		OwnerLogin:  node.Owner.Login,
+		OwnerAvatar: node.Owner.AvatarURL,
+		HTMLURL:     node.URL,
+		Description: desc,
+		Language:    lang,
+		Topics:      topics,
+		Stargazers:  node.StargazerCount,
+		Forks:       node.ForkCount,
+		PushedAt:    node.PushedAt,
+		CreatedAt:   node.CreatedAt,
+		License:     &license,
+		Archived:    node.IsArchived,
+		OpenIssues:  node.Issues.TotalCount,
+		DiskUsageKB: node.DiskUsage,
+	}
+
+	if node.DefaultBranchRef != nil {
+		committed := node.DefaultBranchRef.Target.CommittedDate
+		if !committed.IsZero() {
+			repo.LastCommitAt = &committed
+		}
+		repo.LastCommitMessage = node.DefaultBranchRef.Target.MessageHeadline
+	}
+
+	switch {
+	case node.ReadmeUpper != nil:
+		repo.Readme = node.ReadmeUpper.Text
+	case node.ReadmeLower != nil:
+		repo.Readme = node.ReadmeLower.Text
+	}
+
+	return repo
+}
@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGraphQL serves repository searches over total repos, with cursors
+// that reveal nothing about the offset they stand for. It records each
+// request as "nodes" or "cursor", after the cursor's offset.
+type fakeGraphQL struct {
+	t     *testing.T
+	total int
+
+	mu       sync.Mutex
+	cursors  map[string]int // cursor -> offset of the page it starts
+	requests []string
+}
+
+func (f *fakeGraphQL) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if r.URL.Path != "/graphql" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		f.t.Errorf("unexpected request %s", r.URL)
+		http.NotFound(w, r)
+		return
+	}
+	if q := req.Variables["q"]; q != "language:go sort:stars-desc" {
+		f.t.Errorf("searched %q", q)
+	}
+	first := int(req.Variables["first"].(float64))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	offset := 0
+	if after, ok := req.Variables["after"].(string); ok {
+		if offset, ok = f.cursors[after]; !ok {
+			fmt.Fprintf(w, `{"data":{"search":{"repositoryCount":0,"nodes":[]}},"errors":[{"message":"invalid cursor %s"}]}`, after)
+			return
+		}
+	}
+	kind := "cursor"
+	if strings.Contains(req.Query, "nodes") {
+		kind = "nodes"
+	}
+	f.requests = append(f.requests, fmt.Sprintf("%s@%d", kind, offset))
+
+	end := min(offset+first, f.total)
+	cursor := fmt.Sprintf("opaque-%d", len(f.cursors))
+	f.cursors[cursor] = end
+	search := map[string]interface{}{
+		"repositoryCount": f.total,
+		"pageInfo":        map[string]interface{}{"endCursor": cursor, "hasNextPage": end < f.total},
+	}
+	if kind == "nodes" {
+		nodes := []map[string]interface{}{}
+		for i := offset; i < end; i++ {
+			nodes = append(nodes, map[string]interface{}{
+				"databaseId": i + 1, "name": fmt.Sprint("repo", i+1), "nameWithOwner": fmt.Sprint("someone/repo", i+1),
+				"url": fmt.Sprint("https://github.com/someone/repo", i+1), "owner": map[string]string{"login": "someone"},
+				"stargazerCount": 1000 - i, "pushedAt": "2019-01-01T00:00:00Z", "createdAt": "2015-01-01T00:00:00Z",
+			})
+		}
+		search["nodes"] = nodes
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"search": search}})
+}
+
+func (f *fakeGraphQL) take() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := f.requests
+	f.requests = nil
+	return r
+}
+
+// TestSearchGraphQLPages checks that GraphQL search pages with the cursors
+// GitHub returns, walking to a page through the cursors of the ones before
+// it only when they aren't known yet.
+func TestSearchGraphQLPages(t *testing.T) {
+	fake := &fakeGraphQL{t: t, total: 250, cursors: make(map[string]int)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	c := NewClient([]Credential{StaticToken("ghp_test")}, WithBaseURL(srv.URL), WithGraphQL())
+
+	steps := []struct {
+		page      int
+		requests  string
+		firstID   int64
+		repos     int
+		wantTotal int
+	}{
+		{3, "cursor@0 cursor@100 nodes@200", 201, 50, 250}, // walked to
+		{2, "nodes@100", 101, 100, 250},                    // cursor seen on the way
+		{1, "nodes@0", 1, 100, 250},
+		{4, "cursor@200", 0, 0, 250}, // there is no fourth page
+	}
+	for _, step := range steps {
+		repos, total, err := c.search(context.Background(), "language:go", "stars", step.page, 100)
+		if err != nil {
+			t.Fatalf("page %d: %v", step.page, err)
+		}
+		if got := strings.Join(fake.take(), " "); got != step.requests {
+			t.Errorf("page %d: requests %q, want %q", step.page, got, step.requests)
+		}
+		if len(repos) != step.repos || total != step.wantTotal {
+			t.Errorf("page %d: %d repos of %d, want %d of %d", step.page, len(repos), total, step.repos, step.wantTotal)
+		}
+		if len(repos) > 0 && (repos[0].ID != step.firstID || repos[0].HTMLURL == "") {
+			t.Errorf("page %d: first repo %d (%s), want %d", step.page, repos[0].ID, repos[0].HTMLURL, step.firstID)
+		}
+	}
+}
+
+func TestSearchGraphQLErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"search":{"repositoryCount":0,"nodes":[]}},"errors":[{"message":"Something went wrong"}]}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient([]Credential{StaticToken("ghp_test")}, WithBaseURL(srv.URL), WithGraphQL())
+	if _, _, err := c.search(context.Background(), "language:go", "stars", 1, 100); err == nil || !strings.Contains(err.Error(), "Something went wrong") {
+		t.Errorf("err %v, want the GraphQL error", err)
+	}
+	if _, _, err := NewClient(nil, WithBaseURL(srv.URL), WithGraphQL()).search(context.Background(), "language:go", "stars", 1, 100); err == nil {
+		t.Error("anonymous GraphQL search succeeded")
+	}
+}
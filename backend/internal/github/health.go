@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// testDirs and testFiles mark a repo as tested when found in its root
+// directory. Tests deeper in the tree, such as Go's per-package _test.go
+// files, are only caught through a root-level test directory.
+var (
+	testDirs = map[string]bool{
+		"test": true, "tests": true, "spec": true, "__tests__": true, "testing": true, "t": true,
+	}
+	testFiles = map[string]bool{
+		"pytest.ini": true, "tox.ini": true, "conftest.py": true, ".rspec": true,
+		"phpunit.xml": true, "phpunit.xml.dist": true, "karma.conf.js": true,
+		"jest.config.js": true, "jest.config.ts": true, "vitest.config.ts": true,
+	}
+)
+
+// ciFiles and ciDirs are CI configurations found in a repo's root directory.
+// GitHub Actions workflows are looked up in .github/workflows.
+var (
+	ciFiles = map[string]bool{
+		".travis.yml": true, ".gitlab-ci.yml": true, "Jenkinsfile": true,
+		"azure-pipelines.yml": true, "appveyor.yml": true, ".appveyor.yml": true,
+		"bitbucket-pipelines.yml": true, ".drone.yml": true, ".cirrus.yml": true,
+	}
+	ciDirs = map[string]bool{".circleci": true, ".buildkite": true}
+)
+
+// FetchHealth looks for tests and CI configuration in a repo's root
+// directory and, when it has CI, reads the build status of its default
+// branch. An empty or missing repo has neither.
+func (c *Client) FetchHealth(ctx context.Context, fullName string) (models.Health, error) {
+	health := models.Health{BuildStatus: models.BuildUnknown}
+
+	var entries []contentEntry
+	found, err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/contents/", c.apiURL, fullName), &entries)
+	if err != nil || !found {
+		return health, err
+	}
+
+	hasGitHubDir := false
+	for _, e := range entries {
+		switch e.Type {
+		case "dir":
+			health.HasTests = health.HasTests || testDirs[strings.ToLower(e.Name)]
+			health.HasCI = health.HasCI || ciDirs[e.Name]
+			hasGitHubDir = hasGitHubDir || e.Name == ".github"
+		case "file":
+			health.HasTests = health.HasTests || testFiles[e.Name] || isTestFile(e.Name)
+			health.HasCI = health.HasCI || ciFiles[e.Name]
+		}
+	}
+
+	if !health.HasCI && hasGitHubDir {
+		var workflows []contentEntry
+		if _, err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/contents/.github/workflows", c.apiURL, fullName), &workflows); err != nil {
+			return health, err
+		}
+		health.HasCI = len(workflows) > 0
+	}
+
+	if health.HasCI {
+		health.BuildStatus, err = c.fetchBuildStatus(ctx, fullName)
+		if err != nil {
+			return health, err
+		}
+	}
+	return health, nil
+}
+
+// isTestFile reports whether a file name follows a common test naming
+// convention: foo_test.go, test_foo.py, foo.test.js, foo.spec.ts, ...
+func isTestFile(name string) bool {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	return strings.HasSuffix(base, "_test") || strings.HasPrefix(base, "test_") ||
+		strings.HasSuffix(base, ".test") || strings.HasSuffix(base, ".spec")
+}
+
+// fetchBuildStatus reports whether the latest commit on the default branch
+// passed CI. GitHub Actions and other check-run integrations are read first,
+// then commit statuses, which older services such as Travis CI report.
+func (c *Client) fetchBuildStatus(ctx context.Context, fullName string) (string, error) {
+	var checks struct {
+		CheckRuns []struct {
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+		} `json:"check_runs"`
+	}
+	if _, err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/commits/HEAD/check-runs?per_page=100", c.apiURL, fullName), &checks); err != nil {
+		return models.BuildUnknown, err
+	}
+	if len(checks.CheckRuns) > 0 {
+		status := models.BuildPassing
+		for _, run := range checks.CheckRuns {
+			switch {
+			case run.Status != "completed":
+				status = models.BuildUnknown
+			case run.Conclusion == "failure" || run.Conclusion == "timed_out" || run.Conclusion == "startup_failure":
+				return models.BuildFailing, nil
+			}
+		}
+		return status, nil
+	}
+
+	var combined struct {
+		State      string `json:"state"`
+		TotalCount int    `json:"total_count"`
+	}
+	if _, err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/commits/HEAD/status", c.apiURL, fullName), &combined); err != nil {
+		return models.BuildUnknown, err
+	}
+	switch {
+	case combined.TotalCount == 0:
+		return models.BuildUnknown, nil
+	case combined.State == "success":
+		return models.BuildPassing, nil
+	case combined.State == "failure" || combined.State == "error":
+		return models.BuildFailing, nil
+	}
+	return models.BuildUnknown, nil
+}
+
+// LoadHealth fetches the repo's health and fills it in.
+func (c *Client) LoadHealth(ctx context.Context, repo *models.Repo) error {
+	health, err := c.FetchHealth(ctx, repo.FullName)
+	if err != nil {
+		return err
+	}
+	health.FetchedAt = time.Now()
+	repo.Health = &health
+	return nil
+}
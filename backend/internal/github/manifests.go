@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+	"github.com/ahmetburakdinc/codefossils/internal/stack"
+)
+
+// maxManifestBytes bounds how much of a manifest we read.
+const maxManifestBytes = 256 * 1024
+
+type contentEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// FetchManifests returns the dependency manifests in a repo's root directory,
+// keyed by file name. It lists the directory first, so only manifests that
+// exist are requested. An empty or missing repo has none. A manifest that
+// can't be read is left out rather than losing the others; only a rate limit
+// or cancellation stops the whole fetch.
+func (c *Client) FetchManifests(ctx context.Context, fullName string) (map[string]string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/contents/", c.apiURL, fullName)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.doCached(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var entries []contentEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	files := make(map[string]string)
+	for _, e := range entries {
+		if e.Type != "file" || !stack.IsManifest(e.Name) {
+			continue
+		}
+		content, err := c.fetchFile(ctx, fullName, e.Name)
+		if err != nil {
+			var rlErr *RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				return nil, fmt.Errorf("fetching %s: %w", e.Name, err)
+			}
+			log.Printf("Error fetching %s of %s: %v", e.Name, fullName, err)
+			continue
+		}
+		files[e.Name] = content
+	}
+	return files, nil
+}
+
+// fetchFile returns the raw content of a file at the repo's default branch.
+func (c *Client) fetchFile(ctx context.Context, fullName, path string) (string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/contents/%s", c.apiURL, fullName, url.PathEscape(path))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+
+	resp, err := c.doCached(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes))
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	return string(body), nil
+}
+
+// fileUpdatedAt returns when the file at path was last committed to on the
+// default branch, or nil when no commit touched it.
+func (c *Client) fileUpdatedAt(ctx context.Context, fullName, path string) (*time.Time, error) {
+	var commits []struct {
+		Commit struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/commits?path=%s&per_page=1", c.apiURL, fullName, url.QueryEscape(path))
+	if _, err := c.getJSON(ctx, apiURL, &commits); err != nil || len(commits) == 0 {
+		return nil, err
+	}
+	return &commits[0].Commit.Committer.Date, nil
+}
+
+// LoadStack reads the repo's manifests and fills in its stack and when its
+// dependencies were last updated. A manifest whose history can't be read is
+// left out of the latter, like an unreadable manifest is of the former.
+func (c *Client) LoadStack(ctx context.Context, repo *models.Repo) error {
+	files, err := c.FetchManifests(ctx, repo.FullName)
+	if err != nil {
+		return err
+	}
+	var updated *time.Time
+	for name := range files {
+		at, err := c.fileUpdatedAt(ctx, repo.FullName, name)
+		if err != nil {
+			var rlErr *RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				return fmt.Errorf("dating %s: %w", name, err)
+			}
+			log.Printf("Error dating %s of %s: %v", name, repo.FullName, err)
+			continue
+		}
+		if at != nil && (updated == nil || at.After(*updated)) {
+			updated = at
+		}
+	}
+	now := time.Now()
+	repo.Stack = stack.Detect(files)
+	repo.DependenciesUpdatedAt = updated
+	repo.StackFetchedAt = &now
+	return nil
+}
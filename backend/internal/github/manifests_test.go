@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// TestLoadStack checks that a repo's stack comes from its manifests and its
+// dependencies date from the manifest committed to last, skipping files
+// that aren't manifests and manifests without history.
+func TestLoadStack(t *testing.T) {
+	updated := map[string]string{
+		"package.json": "2016-05-02T10:00:00Z",
+		"go.mod":       "2019-11-20T08:30:00Z",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/someone/app/contents/":
+			fmt.Fprint(w, `[{"name":"package.json","type":"file"},{"name":"go.mod","type":"file"},
+				{"name":"Gemfile","type":"file"},{"name":"README.md","type":"file"},{"name":"src","type":"dir"}]`)
+		case "/repos/someone/app/contents/package.json":
+			fmt.Fprint(w, `{"dependencies":{"react":"^15.0.0"}}`)
+		case "/repos/someone/app/contents/go.mod":
+			fmt.Fprint(w, "module app\n\nrequire github.com/spf13/cobra v0.0.5\n")
+		case "/repos/someone/app/contents/Gemfile":
+			fmt.Fprint(w, "gem 'rails'\n")
+		case "/repos/someone/app/commits":
+			if r.URL.Query().Get("per_page") != "1" {
+				t.Errorf("commits listed with %q", r.URL.RawQuery)
+			}
+			date, ok := updated[r.URL.Query().Get("path")]
+			if !ok {
+				fmt.Fprint(w, `[]`)
+				return
+			}
+			fmt.Fprintf(w, `[{"commit":{"committer":{"date":%q}}}]`, date)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil, WithBaseURL(srv.URL))
+	repo := models.Repo{FullName: "someone/app"}
+	if err := c.LoadStack(context.Background(), &repo); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cobra", "rails", "react"}; !reflect.DeepEqual(repo.Stack, want) {
+		t.Errorf("stack %q, want %q", repo.Stack, want)
+	}
+	want := time.Date(2019, 11, 20, 8, 30, 0, 0, time.UTC)
+	if repo.DependenciesUpdatedAt == nil || !repo.DependenciesUpdatedAt.Equal(want) {
+		t.Errorf("dependencies updated at %v, want %v", repo.DependenciesUpdatedAt, want)
+	}
+	if repo.StackFetchedAt == nil {
+		t.Error("StackFetchedAt not set")
+	}
+}
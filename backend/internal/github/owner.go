@@ -0,0 +1,116 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// ownerStaleAfter is how long a repo must have gone without a push to count
+// towards an owner's stale repos.
+const ownerStaleAfter = 2 * 365 * 24 * time.Hour
+
+// ownerRepoPages caps how many pages of an owner's repos are read. Past
+// that, an owner's stale count is a lower bound.
+const ownerRepoPages = 10
+
+// FetchOwner looks up an account's type and age, its most recent public
+// activity and how many of its repos have gone stale. An account that no
+// longer exists is returned with type models.OwnerDeleted.
+func (c *Client) FetchOwner(ctx context.Context, login string) (models.Owner, error) {
+	owner := models.Owner{Source: c.name, Login: login}
+
+	var user struct {
+		Type      string    `json:"type"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	found, err := c.getJSON(ctx, fmt.Sprintf("%s/users/%s", c.apiURL, url.PathEscape(login)), &user)
+	if err != nil {
+		return owner, err
+	}
+	if !found {
+		owner.Type = models.OwnerDeleted
+		return owner, nil
+	}
+	owner.Type = models.OwnerUser
+	if user.Type == "Organization" {
+		owner.Type = models.OwnerOrg
+	}
+	owner.AccountCreatedAt = &user.CreatedAt
+
+	// Public events cover the last 90 days, including issues and comments
+	// that leave no trace in the owner's own repos.
+	eventsURL := fmt.Sprintf("%s/users/%s/events/public?per_page=1", c.apiURL, url.PathEscape(login))
+	if owner.Type == models.OwnerOrg {
+		eventsURL = fmt.Sprintf("%s/orgs/%s/events?per_page=1", c.apiURL, url.PathEscape(login))
+	}
+	var events []struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if _, err := c.getJSON(ctx, eventsURL, &events); err != nil {
+		return owner, err
+	}
+	if len(events) > 0 {
+		owner.LastActiveAt = &events[0].CreatedAt
+	}
+
+	// The owner's repos, most recently pushed first: the first one dates
+	// activity older than the event window, and all of them give the stale
+	// count.
+	cutoff := c.now().Add(-ownerStaleAfter)
+	for page := 1; page <= ownerRepoPages; page++ {
+		var repos []struct {
+			Fork     bool      `json:"fork"`
+			PushedAt time.Time `json:"pushed_at"`
+		}
+		reposURL := fmt.Sprintf("%s/users/%s/repos?type=owner&sort=pushed&per_page=100&page=%d", c.apiURL, url.PathEscape(login), page)
+		if _, err := c.getJSON(ctx, reposURL, &repos); err != nil {
+			return owner, err
+		}
+		for _, r := range repos {
+			if owner.LastActiveAt == nil || r.PushedAt.After(*owner.LastActiveAt) {
+				pushed := r.PushedAt
+				owner.LastActiveAt = &pushed
+			}
+			if !r.Fork && r.PushedAt.Before(cutoff) {
+				owner.StaleRepos++
+			}
+		}
+		if len(repos) < 100 {
+			break
+		}
+	}
+	return owner, nil
+}
+
+// getJSON fetches a REST resource into v. found is false on 404.
+func (c *Client) getJSON(ctx context.Context, apiURL string, v interface{}) (found bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.doCached(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("decoding response: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,308 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRetries is how many times a rate-limited request is retried before giving up.
+	maxRetries = 5
+	// maxRateLimitWait caps a single wait; anything longer aborts the run instead of stalling it.
+	maxRateLimitWait = 15 * time.Minute
+	// secondaryBackoff is the initial wait for secondary rate limits that carry no Retry-After.
+	secondaryBackoff = time.Minute
+)
+
+// RateLimit is the latest quota snapshot GitHub reported for one API resource
+// (core, search, graphql, ...).
+type RateLimit struct {
+	Resource  string    `json:"resource"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// RateLimitError is returned when a request stays rate limited after retries,
+// or when the required wait exceeds maxRateLimitWait.
+type RateLimitError struct {
+	Resource string
+	Wait     time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub API rate limit exhausted for %q (retry in %s)", e.Resource, e.Wait.Round(time.Second))
+}
+
+// RateLimit returns the last known quota for a resource, summed over every
+// credential in the pool; Reset is the earliest reset among them. ok is false
+// until GitHub has answered at least one request for it.
+func (c *Client) RateLimit(resource string) (RateLimit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.combinedLimit(resource)
+}
+
+// RateLimits returns a copy of every known quota, keyed by resource and
+// summed over the pool like RateLimit.
+func (c *Client) RateLimits() map[string]RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]RateLimit)
+	for _, pc := range c.pool {
+		for resource := range pc.limits {
+			if _, done := out[resource]; !done {
+				out[resource], _ = c.combinedLimit(resource)
+			}
+		}
+	}
+	return out
+}
+
+func (c *Client) combinedLimit(resource string) (RateLimit, bool) {
+	total := RateLimit{Resource: resource}
+	found := false
+	for _, pc := range c.pool {
+		rl, ok := pc.limits[resource]
+		if !ok {
+			continue
+		}
+		total.Limit += rl.Limit
+		total.Remaining += rl.Remaining
+		if !found || rl.Reset.Before(total.Reset) {
+			total.Reset = rl.Reset
+		}
+		found = true
+	}
+	return total, found
+}
+
+// authenticated reports whether the client has any credentials.
+func (c *Client) authenticated() bool {
+	return c.pool[0].cred != nil
+}
+
+// do sends req with the pooled credential that has the most quota left,
+// waiting out exhausted quotas and retrying primary and secondary rate
+// limits with backoff. Waits end early when the request's context is
+// cancelled. The caller owns the returned body.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	resource := resourceFor(req)
+
+	for attempt := 0; ; attempt++ {
+		pc, err := c.acquire(ctx, resource)
+		if err != nil {
+			return nil, err
+		}
+		if pc.cred != nil {
+			token, err := pc.cred.Token(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("getting token for %s: %w", pc.cred.ID(), err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if c.apiVersion != "" {
+			req.Header.Set("X-GitHub-Api-Version", c.apiVersion)
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewinding request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		c.updateRateLimit(pc, resource, resp.Header)
+
+		limited, err := isRateLimited(resp)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		if !limited {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		// This credential's quota is spent; switch at once if another has some.
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" && attempt < maxRetries && c.hasQuota(resource) {
+			log.Printf("GitHub %s quota exhausted for %s, switching credentials", resource, pc.name())
+			continue
+		}
+
+		wait := retryDelay(resp, attempt)
+		if attempt >= maxRetries || wait > maxRateLimitWait {
+			return nil, &RateLimitError{Resource: resource, Wait: wait}
+		}
+		log.Printf("GitHub rate limit hit on %s (HTTP %d), retrying in %s", resource, resp.StatusCode, wait.Round(time.Second))
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// acquire picks the credential with the most remaining quota for resource.
+// Credentials GitHub hasn't reported on yet count as full. When every quota
+// is exhausted it blocks until the earliest reset or until ctx is done.
+func (c *Client) acquire(ctx context.Context, resource string) (*pooledCredential, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	var best *pooledCredential
+	var bestRL RateLimit
+	bestLeft := 0
+	for _, pc := range c.pool {
+		rl, ok := pc.limits[resource]
+		left := rl.Remaining
+		if !ok || !rl.Reset.After(now) {
+			left = math.MaxInt
+		}
+		switch {
+		case best == nil,
+			left > bestLeft,
+			left == 0 && bestLeft == 0 && rl.Reset.Before(bestRL.Reset):
+			best, bestRL, bestLeft = pc, rl, left
+		}
+	}
+	c.mu.Unlock()
+
+	if bestLeft > 0 {
+		return best, nil
+	}
+	wait := time.Until(bestRL.Reset) + time.Second
+	if wait > maxRateLimitWait {
+		return nil, &RateLimitError{Resource: resource, Wait: wait}
+	}
+	log.Printf("GitHub %s quota exhausted for all credentials, waiting %s for reset", resource, wait.Round(time.Second))
+	if err := sleep(ctx, wait); err != nil {
+		return nil, err
+	}
+	return best, nil
+}
+
+// sleep waits for d, returning ctx's error if it is cancelled first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hasQuota reports whether any credential may still have quota for resource.
+func (c *Client) hasQuota(resource string) bool {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pc := range c.pool {
+		rl, ok := pc.limits[resource]
+		if !ok || rl.Remaining > 0 || !rl.Reset.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) updateRateLimit(pc *pooledCredential, resource string, h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	resetUnix, _ := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if r := h.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+
+	c.mu.Lock()
+	pc.limits[resource] = RateLimit{
+		Resource:  resource,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(resetUnix, 0),
+	}
+	c.mu.Unlock()
+}
+
+func (pc *pooledCredential) name() string {
+	if pc.cred == nil {
+		return "anonymous access"
+	}
+	return pc.cred.ID()
+}
+
+// retryDelay picks the wait before the next attempt: Retry-After if present,
+// the quota reset time if the primary limit is exhausted, otherwise an
+// exponential backoff for secondary limits. All waits get a little jitter.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	var wait time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		resetUnix, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		wait = time.Until(time.Unix(resetUnix, 0)) + time.Second
+	} else {
+		wait = secondaryBackoff << attempt
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait/4)+1))
+}
+
+// isRateLimited reports whether resp is a primary or secondary rate limit
+// response. Secondary limits are recognised by their message, which older
+// GitHub Enterprise versions word as abuse detection. Plain 403s (e.g.
+// blocked repos) are left intact for the caller.
+func isRateLimited(resp *http.Response) (bool, error) {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, nil
+	case http.StatusForbidden:
+	default:
+		return false, nil
+	}
+
+	if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return true, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return false, fmt.Errorf("reading 403 response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "rate limit") || strings.Contains(msg, "abuse detection"), nil
+}
+
+// resourceFor maps a request to the GitHub quota bucket it draws from.
+func resourceFor(req *http.Request) string {
+	switch {
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	default:
+		return "core"
+	}
+}
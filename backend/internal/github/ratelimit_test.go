@@ -0,0 +1,204 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	reset := fmt.Sprint(time.Now().Add(2 * time.Minute).Unix())
+	tests := []struct {
+		name     string
+		header   map[string]string
+		attempt  int
+		min, max time.Duration
+	}{
+		{"retry-after", map[string]string{"Retry-After": "30"}, 0, 30 * time.Second, 37500 * time.Millisecond},
+		{"retry-after wins", map[string]string{"Retry-After": "30", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, 0, 30 * time.Second, 37500 * time.Millisecond},
+		{"quota reset", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, 0, 119 * time.Second, 152 * time.Second},
+		{"secondary backoff", nil, 2, 4 * time.Minute, 5 * time.Minute},
+		{"at least a second", map[string]string{"Retry-After": "0"}, 0, time.Second, 1250 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		for k, v := range tt.header {
+			resp.Header.Set(k, v)
+		}
+		if got := retryDelay(resp, tt.attempt); got < tt.min || got > tt.max {
+			t.Errorf("%s: retryDelay = %s, want %s to %s", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header map[string]string
+		body   string
+		want   bool
+	}{
+		{"too many requests", http.StatusTooManyRequests, nil, "", true},
+		{"quota exhausted", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, "", true},
+		{"retry-after", http.StatusForbidden, map[string]string{"Retry-After": "60"}, "", true},
+		{"secondary", http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`, true},
+		{"abuse", http.StatusForbidden, nil, `{"message":"You have triggered an abuse detection mechanism and have been temporarily blocked","documentation_url":"https://docs.github.com/rest/overview/rate-limits-for-the-rest-api"}`, true},
+		{"blocked repo", http.StatusForbidden, nil, `{"message":"Repository access blocked"}`, false},
+		{"not found", http.StatusNotFound, nil, "", false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}
+		for k, v := range tt.header {
+			resp.Header.Set(k, v)
+		}
+		got, err := isRateLimited(resp)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: isRateLimited = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+		// The caller still gets the body of a response that isn't retried.
+		if body, _ := io.ReadAll(resp.Body); !got && string(body) != tt.body {
+			t.Errorf("%s: body %q left, want %q", tt.name, body, tt.body)
+		}
+	}
+}
+
+// countingServer serves each request with respond, numbered from 1.
+func countingServer(t *testing.T, respond func(w http.ResponseWriter, n int32)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, n.Add(1))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func get(t *testing.T, ctx context.Context, c *Client, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.do(req)
+}
+
+// TestDoRetriesAfter checks that a 429 is retried once its Retry-After has
+// passed.
+func TestDoRetriesAfter(t *testing.T) {
+	srv, requests := countingServer(t, func(w http.ResponseWriter, n int32) {
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+	c := NewClient(nil, WithBaseURL(srv.URL))
+
+	start := time.Now()
+	resp, err := get(t, context.Background(), c, srv.URL+"/repos/someone/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("HTTP %d after %d requests, want 200 after 2", resp.StatusCode, requests.Load())
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, want at least Retry-After's second", waited)
+	}
+}
+
+// TestDoQuotaResetTooFar checks that an exhausted quota resetting beyond
+// maxRateLimitWait fails at once, and that later requests for the same
+// resource aren't sent until it resets.
+func TestDoQuotaResetTooFar(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	srv, requests := countingServer(t, func(w http.ResponseWriter, n int32) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+		w.Header().Set("X-RateLimit-Resource", "core")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"API rate limit exceeded"}`)
+	})
+	c := NewClient(nil, WithBaseURL(srv.URL))
+
+	for i := 0; i < 2; i++ {
+		_, err := get(t, context.Background(), c, srv.URL+"/repos/someone/app")
+		var rlErr *RateLimitError
+		if !errors.As(err, &rlErr) || rlErr.Resource != "core" || rlErr.Wait <= maxRateLimitWait {
+			t.Fatalf("request %d: err %v, want a core RateLimitError waiting over %s", i, err, maxRateLimitWait)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests sent, want 1", n)
+	}
+	if rl, ok := c.RateLimit("core"); !ok || rl.Remaining != 0 || rl.Reset.Unix() != reset.Unix() {
+		t.Errorf("core limit %+v (known %v), want none left until %s", rl, ok, reset)
+	}
+}
+
+// TestDoWaitCancelled checks that waiting out a secondary rate limit, which
+// backs off for a minute, ends when the context does.
+func TestDoWaitCancelled(t *testing.T) {
+	srv, requests := countingServer(t, func(w http.ResponseWriter, n int32) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit."}`)
+	})
+	c := NewClient(nil, WithBaseURL(srv.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := get(t, ctx, c, srv.URL+"/search/repositories?q=x")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err %v, want the context's deadline", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("returned after %s, want soon after the deadline", waited)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests sent, want 1", n)
+	}
+}
+
+// TestAcquireWaitsForReset checks that with every quota exhausted, acquire
+// waits for the earliest reset, and stops waiting when the context ends.
+func TestAcquireWaitsForReset(t *testing.T) {
+	c := NewClient([]Credential{StaticToken("ghp_one"), StaticToken("ghp_two")})
+	exhaust := func(i int, reset time.Time) {
+		c.updateRateLimit(c.pool[i], "search", http.Header{
+			"X-Ratelimit-Limit":     {"30"},
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {fmt.Sprint(reset.Unix())},
+		})
+	}
+	exhaust(0, time.Now().Add(10*time.Minute))
+	exhaust(1, time.Now().Add(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.acquire(ctx, "search"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire = %v, want to wait until the deadline", err)
+	}
+
+	// Once a reset has passed its credential counts as full again.
+	exhaust(1, time.Now().Add(-time.Second))
+	pc, err := c.acquire(context.Background(), "search")
+	if err != nil || pc != c.pool[1] {
+		t.Errorf("acquire = %v, %v, want the credential whose quota reset", pc.name(), err)
+	}
+	// The other resources were never limited.
+	if pc, err := c.acquire(context.Background(), "core"); err != nil || pc == nil {
+		t.Errorf("acquire core = %v", err)
+	}
+}
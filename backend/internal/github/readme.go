@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+	"github.com/ahmetburakdinc/codefossils/internal/readme"
+)
+
+// maxReadmeBytes bounds how much of a README we keep.
+const maxReadmeBytes = 512 * 1024
+
+// FetchReadme returns the raw markdown of a repo's README, or "" if it has none.
+func (c *Client) FetchReadme(ctx context.Context, fullName string) (string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/readme", c.apiURL, fullName)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+
+	resp, err := c.doCached(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", nil
+	default:
+		return "", fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxReadmeBytes))
+	if err != nil {
+		return "", fmt.Errorf("reading README: %w", err)
+	}
+	return string(body), nil
+}
+
+// LoadReadme fills in the repo's README, excerpt and image URLs, fetching
+// the README only if the search didn't already return it.
+func (c *Client) LoadReadme(ctx context.Context, repo *models.Repo) error {
+	if repo.Readme == "" {
+		md, err := c.FetchReadme(ctx, repo.FullName)
+		if err != nil {
+			return err
+		}
+		repo.Readme = md
+	}
+	// Fetched or returned with the search result, the README is stored, and
+	// maxReadmeBytes may have cut it mid-character.
+	repo.Readme = readme.Clean(repo.Readme)
+	now := time.Now()
+	repo.ReadmeExcerpt = readme.Excerpt(repo.Readme)
+	repo.ReadmeImages = readme.ImageURLs(repo.Readme, repo.HTMLURL)
+	repo.ReadmeFetchedAt = &now
+	return nil
+}
@@ -0,0 +1,43 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// TestLoadReadmeTruncatedRune checks that a README cut off by
+// maxReadmeBytes in the middle of a character, or holding NUL bytes, is
+// stored as valid text.
+func TestLoadReadmeTruncatedRune(t *testing.T) {
+	// "€" is three bytes; the limit falls after its first.
+	body := "# Price\x00\n" + strings.Repeat("a", maxReadmeBytes-len("# Price\x00\n")-1) + "€ and more"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/someone/app/readme" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil, WithBaseURL(srv.URL))
+	repo := models.Repo{FullName: "someone/app", HTMLURL: "https://github.com/someone/app"}
+	if err := c.LoadReadme(context.Background(), &repo); err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(repo.Readme) || strings.Contains(repo.Readme, "\x00") {
+		t.Errorf("README is not valid text: ends %q", repo.Readme[len(repo.Readme)-8:])
+	}
+	if want := maxReadmeBytes - 2; len(repo.Readme) != want {
+		t.Errorf("README has %d bytes, want %d", len(repo.Readme), want)
+	}
+	if !utf8.ValidString(repo.ReadmeExcerpt) || repo.ReadmeFetchedAt == nil {
+		t.Errorf("excerpt %q, fetched at %v", repo.ReadmeExcerpt, repo.ReadmeFetchedAt)
+	}
+}
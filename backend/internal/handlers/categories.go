@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// categoryIDRe matches top-level IDs such as "dev-tools" and subcategory IDs
+// such as "dev-tools/cli".
+var categoryIDRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*(/[a-z0-9]+(-[a-z0-9]+)*)?$`)
+
+// CategoryHandler serves the taxonomy to the frontend and the admin API that
+// edits it. Every edit reloads the keyword categorizer's taxonomy, and when
+// the rules changed, calls onChange to recategorize stored repos.
+type CategoryHandler struct {
+	store    *database.CategoryStore
+	keywords *models.KeywordCategorizer
+	onChange func()
+	mu       sync.Mutex // serializes edits, so each is checked against the last
+}
+
+func NewCategoryHandler(store *database.CategoryStore, keywords *models.KeywordCategorizer, onChange func()) *CategoryHandler {
+	return &CategoryHandler{store: store, keywords: keywords, onChange: onChange}
+}
+
+// List returns every category in sort order.
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.keywords.Taxonomy().Categories())
+}
+
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var c models.Category
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	current := h.keywords.Taxonomy()
+	if current.Has(c.ID) {
+		writeError(w, http.StatusConflict, "category already exists")
+		return
+	}
+	if msg := validateCategory(&c, current.Categories()); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	created, err := h.store.Create(c)
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	h.reload()
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Decode over the stored category so the body may be partial.
+	c, err := h.store.Get(id)
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	c.ID = id
+	var others []models.Category
+	for _, other := range h.keywords.Taxonomy().Categories() {
+		if other.ID != id {
+			others = append(others, other)
+		}
+	}
+	if msg := validateCategory(&c, others); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	updated, err := h.store.Update(c)
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	h.reload()
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == models.CategoryOther {
+		writeError(w, http.StatusBadRequest, "the other category can't be deleted")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range h.keywords.Taxonomy().Categories() {
+		if c.Parent == id {
+			writeError(w, http.StatusConflict, "category has subcategories")
+			return
+		}
+	}
+	if err := h.store.Delete(id); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	h.reload()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reload swaps in the stored taxonomy and recategorizes if its rules changed.
+func (h *CategoryHandler) reload() {
+	t, err := h.store.Taxonomy()
+	if err != nil {
+		log.Printf("Error reloading categories: %v", err)
+		return
+	}
+	before := h.keywords.Version()
+	h.keywords.SetTaxonomy(t)
+	if t.Version() != before {
+		log.Printf("Category rules changed to %s, recategorizing", t.Version())
+		h.onChange()
+	}
+}
+
+func (h *CategoryHandler) storeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "category not found")
+		return
+	}
+	log.Printf("Error accessing categories: %v", err)
+	writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+// validateCategory normalizes c and checks it, and the taxonomy it would
+// form with the others, for errors. NewTaxonomy checks the parent.
+func validateCategory(c *models.Category, others []models.Category) string {
+	if !categoryIDRe.MatchString(c.ID) || len(c.ID) > 64 || c.ID == "all" {
+		return `id must be 1-64 lowercase letters, digits and dashes, with one slash for a subcategory, and not "all"`
+	}
+	c.Label = strings.TrimSpace(c.Label)
+	if c.Label == "" || len(c.Label) > 64 {
+		return "label must be 1-64 characters"
+	}
+	if len(c.Icon) > 32 {
+		return "icon must be at most 32 bytes"
+	}
+	if len(c.Description) > 256 {
+		return "description must be at most 256 characters"
+	}
+	c.Keywords = trimmed(c.Keywords, false)
+	c.Stack = trimmed(c.Stack, true)
+
+	all := append(append([]models.Category(nil), others...), *c)
+	if _, err := models.NewTaxonomy(all); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// trimmed returns the non-empty entries of s, trimmed and optionally
+// lowercased.
+func trimmed(s []string, lower bool) []string {
+	out := []string{}
+	for _, v := range s {
+		v = strings.TrimSpace(v)
+		if lower {
+			v = strings.ToLower(v)
+		}
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
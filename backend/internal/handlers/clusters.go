@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+)
+
+// ClusterHandler serves idea clusters: every attempt at the same idea.
+type ClusterHandler struct {
+	store *database.ClusterStore
+}
+
+func NewClusterHandler(store *database.ClusterStore) *ClusterHandler {
+	return &ClusterHandler{store: store}
+}
+
+// Get returns a cluster with all of its repos, representative first.
+func (h *ClusterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	cluster, err := h.store.Get(id)
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "cluster not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading cluster %d: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, cluster)
+}
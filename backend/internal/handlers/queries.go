@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/github"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+var validSortOptions = map[string]bool{
+	"stars": true, "forks": true, "updated": true, "best-match": true,
+}
+
+// QueryHandler serves the admin API for discovery queries.
+type QueryHandler struct {
+	store *database.QueryStore
+}
+
+func NewQueryHandler(store *database.QueryStore) *QueryHandler {
+	return &QueryHandler{store: store}
+}
+
+func (h *QueryHandler) List(w http.ResponseWriter, r *http.Request) {
+	queries, err := h.store.List()
+	if err != nil {
+		log.Printf("Error listing queries: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, queries)
+}
+
+func (h *QueryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	// Unset fields fall back to the defaults of the built-in queries.
+	q := github.DefaultQueries()[0]
+	q.Query = ""
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if msg := validateQuery(&q); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	created, err := h.store.Create(q)
+	if err != nil {
+		log.Printf("Error creating query: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *QueryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode over the stored query so the body may be partial.
+	q, err := h.store.Get(id)
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	q.ID = id
+	if msg := validateQuery(&q); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	updated, err := h.store.Update(q)
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (h *QueryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(id); err != nil {
+		h.storeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *QueryHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	runs, err := h.store.Runs(id, limit)
+	if err != nil {
+		h.storeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (h *QueryHandler) storeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "query not found")
+		return
+	}
+	log.Printf("Error accessing queries: %v", err)
+	writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+func validateQuery(q *models.DiscoveryQuery) string {
+	q.Query = strings.TrimSpace(q.Query)
+	if q.Query == "" || len(q.Query) > 256 {
+		return "query must be 1-256 characters"
+	}
+	if q.StaleAfterDays < 1 {
+		return "stale_after_days must be positive"
+	}
+	if q.MinStars < 0 {
+		return "min_stars must not be negative"
+	}
+	if len(q.SortOptions) == 0 {
+		return "sort_options must not be empty"
+	}
+	for _, s := range q.SortOptions {
+		if !validSortOptions[s] {
+			return "unknown sort option " + strconv.Quote(s)
+		}
+	}
+	return ""
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+	return id, true
+}
@@ -1,48 +1,97 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ahmetburakdinc/codefossils/internal/database"
 	"github.com/ahmetburakdinc/codefossils/internal/github"
 	"github.com/ahmetburakdinc/codefossils/internal/models"
+	"github.com/ahmetburakdinc/codefossils/internal/readme"
+	"github.com/ahmetburakdinc/codefossils/internal/sources"
 )
 
-const refreshCooldown = 5 * time.Minute
+const (
+	refreshCooldown = 5 * time.Minute
+	// defaultRefreshTimeout bounds a refresh run until SetLifetime says otherwise.
+	defaultRefreshTimeout = 10 * time.Minute
+)
 
 type RepoHandler struct {
 	store         *database.RepoStore
+	queries       *database.QueryStore
 	ghClient      *github.Client
-	mu            sync.Mutex
+	sources       []sources.Source
+	evaluator     models.Evaluator
+	keywords      *models.KeywordCategorizer
+	crawlCursors  github.CursorStore // nil in sample mode
+	crawlPages    int
+	mu            sync.Mutex // held for the duration of a refresh run
 	lastRefreshAt time.Time
+
+	// ctx parents refreshes that outlive the request that started them.
+	ctx            context.Context
+	refreshTimeout time.Duration
 }
 
-func NewRepoHandler(store *database.RepoStore, ghClient *github.Client) *RepoHandler {
+// NewRepoHandler creates a handler that refreshes from srcs and scores and
+// categorizes with evaluator. ghClient is also used for GitHub-only work
+// such as fetching READMEs. The category filter accepts the categories of
+// keywords' taxonomy.
+func NewRepoHandler(store *database.RepoStore, queries *database.QueryStore, ghClient *github.Client, srcs []sources.Source, evaluator models.Evaluator, keywords *models.KeywordCategorizer) *RepoHandler {
 	return &RepoHandler{
-		store:    store,
-		ghClient: ghClient,
+		store:     store,
+		queries:   queries,
+		ghClient:  ghClient,
+		sources:   srcs,
+		evaluator: evaluator,
+		keywords:  keywords,
+
+		ctx:            context.Background(),
+		refreshTimeout: defaultRefreshTimeout,
 	}
 }
 
-var validCategories = map[string]bool{
-	"all": true, "web": true, "mobile": true, "ai": true,
-	"dev-tools": true, "data": true, "game": true, "other": true,
+// SetLifetime ties refreshes started by RefreshRepos to ctx, which the
+// server cancels on shutdown, and bounds every refresh run by timeout.
+func (h *RepoHandler) SetLifetime(ctx context.Context, timeout time.Duration) {
+	h.ctx = ctx
+	h.refreshTimeout = timeout
+}
+
+// EnableCrawl switches GitHub refreshes from random sampling to the
+// exhaustive date-sliced crawl, making at most pagesPerRun search requests
+// per refresh. Other sources keep sampling.
+func (h *RepoHandler) EnableCrawl(cursors github.CursorStore, pagesPerRun int) {
+	h.crawlCursors = cursors
+	h.crawlPages = pagesPerRun
+}
+
+var validStatuses = map[string]bool{
+	"all": true, models.StatusFossil: true, models.StatusArchived: true,
+	models.StatusRenamed: true, models.StatusRevived: true, models.StatusDeleted: true,
 }
 
+var spdxIDRe = regexp.MustCompile(`^[A-Za-z0-9.+-]{1,64}$`)
+
 func (h *RepoHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	category := q.Get("category")
 	sort := q.Get("sort")
 	search := q.Get("search")
 
-	// Validate category
-	if category != "" && !validCategories[category] {
-		category = ""
+	// A category lists its subcategories' repos too
+	var categories []string
+	if taxonomy := h.keywords.Taxonomy(); taxonomy.Has(category) {
+		categories = taxonomy.Subtree(category)
 	}
 
 	// Cap search length
@@ -62,7 +111,55 @@ func (h *RepoHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 		perPage = 30
 	}
 
-	repos, total, err := h.store.Query(category, sort, search, page, perPage)
+	status := q.Get("status")
+	if status != "" && !validStatuses[status] {
+		status = ""
+	}
+
+	source := q.Get("source")
+	if source != "" && !models.ValidSource(source) {
+		source = ""
+	}
+
+	// stack=django or stack=react,electron (repos using all of them)
+	var stack []string
+	for _, name := range strings.Split(q.Get("stack"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && len(name) <= 50 && len(stack) < 5 {
+			stack = append(stack, name)
+		}
+	}
+
+	// license=permissive|copyleft|unknown|none, or an SPDX id such as MIT
+	license := q.Get("license")
+	if !spdxIDRe.MatchString(license) {
+		license = ""
+	}
+
+	var ownerActive *bool
+	if v, err := strconv.ParseBool(q.Get("owner_active")); err == nil {
+		ownerActive = &v
+	}
+
+	repos, total, err := h.store.Query(database.RepoQuery{
+		Categories:    categories,
+		Sort:          sort,
+		Search:        search,
+		Status:        status,
+		Source:        source,
+		Stack:         stack,
+		License:       license,
+		RevivableOnly: q.Get("revivable_only") == "true",
+		OwnerActive:   ownerActive,
+		Breakdown:     q.Get("breakdown") == "true",
+		Page:          page,
+		PerPage:       perPage,
+
+		MinRevivability: scoreParam(q.Get("min_revivability")),
+		MaxRevivability: scoreParam(q.Get("max_revivability")),
+
+		CollapseClusters: q.Get("collapse_clusters") == "true",
+	})
 	if err != nil {
 		log.Printf("Error querying repos: %v", err)
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
@@ -80,6 +177,18 @@ func (h *RepoHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// scoreParam parses a 0-100 score bound, clamping out-of-range values. An
+// empty or invalid value is nil, which leaves the bound unset; 0 and 100
+// are bounds like any other.
+func scoreParam(v string) *int {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil
+	}
+	n = max(0, min(n, 100))
+	return &n
+}
+
 func (h *RepoHandler) RefreshRepos(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
@@ -95,7 +204,7 @@ func (h *RepoHandler) RefreshRepos(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusTooManyRequests)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error":      "refresh on cooldown",
+			"error":       "refresh on cooldown",
 			"retry_after": int(remaining.Seconds()),
 		})
 		return
@@ -111,45 +220,283 @@ func (h *RepoHandler) RefreshRepos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The refresh outlives this request, so it hangs off the handler's
+	// context rather than r.Context().
 	go func() {
 		defer h.mu.Unlock()
-		h.doRefresh()
+		h.doRefresh(h.ctx)
 	}()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "refresh started"})
 }
 
-func (h *RepoHandler) doRefresh() {
-	repos, err := h.ghClient.FetchStaleRepos()
+// doRefresh runs every source under one deadline. Whatever was fetched
+// before the deadline or a cancellation is still stored.
+func (h *RepoHandler) doRefresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.refreshTimeout)
+	defer cancel()
+
+	queries, err := h.queries.Enabled()
 	if err != nil {
-		log.Printf("Error fetching from GitHub: %v", err)
+		log.Printf("Error loading discovery queries: %v", err)
+		return
 	}
-	if len(repos) > 0 {
-		count, err := h.store.UpsertBatch(repos)
+	if len(queries) == 0 {
+		log.Println("No enabled discovery queries, skipping refresh")
+		return
+	}
+
+	upserted := 0
+	for _, src := range h.sources {
+		if ctx.Err() != nil {
+			log.Printf("Refresh stopped before %s: %v", src.Name(), ctx.Err())
+			break
+		}
+		upserted += h.refreshSource(ctx, src, queries)
+	}
+	if upserted > 0 {
+		log.Printf("Upserted %d repos", upserted)
+	}
+}
+
+// refreshSource runs the queries against one source and stores the results,
+// returning how many repos were upserted.
+func (h *RepoHandler) refreshSource(ctx context.Context, src sources.Source, queries []models.DiscoveryQuery) int {
+	var results []models.QueryResult
+	var err error
+	if gh, ok := src.(*github.Client); ok && h.crawlCursors != nil {
+		results, err = gh.Crawl(ctx, queries, h.crawlCursors, h.crawlPages)
+	} else {
+		results, err = src.FetchStaleRepos(ctx, queries)
+	}
+	if err != nil {
+		log.Printf("Error fetching from %s: %v", src.Name(), err)
+	}
+	if src == sources.Source(h.ghClient) {
+		if rl, ok := h.ghClient.RateLimit("search"); ok {
+			log.Printf("GitHub search quota: %d/%d remaining, resets at %s", rl.Remaining, rl.Limit, rl.Reset.Format(time.RFC3339))
+		}
+	}
+
+	upserted := 0
+	for _, res := range results {
+		run, err := h.storeResult(ctx, src.Name(), res)
 		if err != nil {
-			log.Printf("Error upserting repos: %v", err)
-		} else {
-			log.Printf("Upserted %d repos", count)
+			log.Printf("Error storing %s results for query %q: %v", src.Name(), res.Query.Query, err)
+			continue
+		}
+		upserted += len(res.Repos)
+		if err := h.queries.RecordRun(run); err != nil {
+			log.Printf("Error recording run for query %q: %v", res.Query.Query, err)
 		}
 	}
+	return upserted
+}
+
+// storeResult upserts one query's repos and measures its yield: how many
+// were new, how many we already had, and how good the new ones are.
+func (h *RepoHandler) storeResult(ctx context.Context, source string, res models.QueryResult) (models.QueryRun, error) {
+	run := models.QueryRun{
+		QueryID:    res.Query.ID,
+		Fetched:    len(res.Repos) + res.Duplicates,
+		Duplicates: res.Duplicates,
+	}
+
+	ids := make([]int64, len(res.Repos))
+	for i, repo := range res.Repos {
+		ids[i] = repo.ID
+	}
+	h.prepare(ctx, source, res.Repos, ids)
+
+	existing, err := h.store.ExistingIDs(source, ids)
+	if err != nil {
+		return run, err
+	}
+
+	scoreSum := 0
+	for _, repo := range res.Repos {
+		if existing[repo.ID] {
+			run.Duplicates++
+			continue
+		}
+		run.NewRepos++
+		scoreSum += repo.IdeaScore
+	}
+	if run.NewRepos > 0 {
+		run.AvgIdeaScore = float64(scoreSum) / float64(run.NewRepos)
+	}
+
+	if _, err := h.store.UpsertBatch(res.Repos); err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+// prepare attaches READMEs and stacks (fetching only those not stored yet)
+// and computes each repo's score and category, which depend on both.
+// READMEs and manifests are only fetched for repos from the GitHub client's
+// deployment, and not at all once ctx is done.
+func (h *RepoHandler) prepare(ctx context.Context, source string, repos []models.Repo, ids []int64) {
+	stored, err := h.store.StoredReadmeExcerpts(source, ids)
+	if err != nil {
+		log.Printf("Error loading stored READMEs: %v", err)
+		stored = map[int64]string{}
+	}
+	stacks, err := h.store.StoredStacks(source, ids)
+	if err != nil {
+		log.Printf("Error loading stored stacks: %v", err)
+		stacks = map[int64]database.StoredStack{}
+	}
+	// Health is left to the enrichment job, but stored health still counts
+	// towards the revivability score.
+	health, err := h.store.StoredHealth(source, ids)
+	if err != nil {
+		log.Printf("Error loading stored health: %v", err)
+		health = map[int64]models.Health{}
+	}
+
+	fetch := source == h.ghClient.Name()
+	rateLimited := false
+	for i := range repos {
+		repo := &repos[i]
+		if excerpt, ok := stored[repo.ID]; ok && repo.Readme == "" {
+			repo.ReadmeExcerpt = excerpt
+		} else if fetch && !rateLimited && ctx.Err() == nil {
+			if err := h.ghClient.LoadReadme(ctx, repo); err != nil {
+				rateLimited = logFetchError(ctx, "README", repo, err)
+			}
+		}
+		if s, ok := stacks[repo.ID]; ok {
+			repo.Stack, repo.DependenciesUpdatedAt = s.Stack, s.DependenciesUpdatedAt
+		} else if fetch && !rateLimited && ctx.Err() == nil {
+			if err := h.ghClient.LoadStack(ctx, repo); err != nil {
+				rateLimited = logFetchError(ctx, "stack", repo, err)
+			}
+		}
+		if hl, ok := health[repo.ID]; ok {
+			repo.Health = &hl
+		}
+		repo.Evaluate(h.evaluator)
+	}
+}
+
+// logFetchError logs a failed per-repo fetch, unless the run was cancelled,
+// and reports whether it was a rate limit.
+func logFetchError(ctx context.Context, what string, repo *models.Repo, err error) bool {
+	if ctx.Err() == nil {
+		log.Printf("Error loading %s for %s: %v", what, repo.FullName, err)
+	}
+	var rlErr *github.RateLimitError
+	return errors.As(err, &rlErr)
+}
+
+// GetRepo serves one repo with its owner and score breakdown. The source
+// query parameter defaults to github.
+func (h *RepoHandler) GetRepo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		source = models.SourceGitHub
+	}
+
+	repo, err := h.store.Get(source, id)
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "repo not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading %s repo %d: %v", source, id, err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, repo)
+}
+
+// Readme serves a repo's README as sanitized HTML. The source query
+// parameter defaults to github.
+func (h *RepoHandler) Readme(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		source = models.SourceGitHub
+	}
+
+	repo, err := h.store.GetReadme(source, id)
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "repo not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading README for %s repo %d: %v", source, id, err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if repo.ReadmeFetchedAt == nil {
+		writeError(w, http.StatusNotFound, "README not fetched yet")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ReadmeResponse{
+		Source:  repo.Source,
+		ID:      repo.ID,
+		HTML:    readme.RenderHTML(repo.Readme, repo.HTMLURL),
+		Excerpt: repo.ReadmeExcerpt,
+		Images:  repo.ReadmeImages,
+	})
 }
 
 func (h *RepoHandler) Stats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.store.Stats()
+	stats, total, err := h.store.Stats()
 	if err != nil {
 		log.Printf("Error getting stats: %v", err)
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
 		return
 	}
 
-	total := 0
-	for _, count := range stats {
-		total += count
+	taxonomy := h.keywords.Taxonomy()
+	subtrees := make(map[string][]string)
+	for _, c := range taxonomy.Categories() {
+		if c.Parent == "" {
+			subtrees[c.ID] = taxonomy.Subtree(c.ID)
+		}
+	}
+	rollups, err := h.store.Rollups(subtrees)
+	if err != nil {
+		log.Printf("Error getting stats: %v", err)
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Categories come in tree order, each parent before its subcategories.
+	tree := []models.CategoryStats{}
+	for _, c := range taxonomy.Categories() {
+		node := models.CategoryStats{ID: c.ID, Label: c.Label, Icon: c.Icon, Count: stats[c.ID]}
+		if c.Parent == "" {
+			node.Count = rollups[c.ID]
+			tree = append(tree, node)
+			continue
+		}
+		for i := range tree {
+			if tree[i].ID == c.Parent {
+				tree[i].Children = append(tree[i].Children, node)
+			}
+		}
 	}
 
 	resp := models.StatsResponse{
 		Categories: stats,
+		Tree:       tree,
 		Total:      total,
 	}
 
@@ -157,12 +504,13 @@ func (h *RepoHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-// DoRefreshSync performs a synchronous refresh (used by scheduler).
-func (h *RepoHandler) DoRefreshSync() {
+// DoRefreshSync performs a synchronous refresh (used by scheduler). It
+// returns early, releasing the refresh lock, when ctx is cancelled.
+func (h *RepoHandler) DoRefreshSync(ctx context.Context) {
 	if !h.mu.TryLock() {
 		log.Println("Refresh already in progress, skipping scheduled refresh")
 		return
 	}
 	defer h.mu.Unlock()
-	h.doRefresh()
+	h.doRefresh(ctx)
 }
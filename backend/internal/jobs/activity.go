@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/github"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// ActivityEnricher fetches weekly commit activity for stored repos and
+// records how each one died: when it peaked and how long it lingered after.
+type ActivityEnricher struct {
+	store     *database.RepoStore
+	ghClient  *github.Client
+	batchSize int
+	mu        sync.Mutex
+}
+
+func NewActivityEnricher(store *database.RepoStore, ghClient *github.Client, batchSize int) *ActivityEnricher {
+	return &ActivityEnricher{
+		store:     store,
+		ghClient:  ghClient,
+		batchSize: batchSize,
+	}
+}
+
+func (e *ActivityEnricher) Run(ctx context.Context) {
+	if !e.mu.TryLock() {
+		log.Println("Activity enrichment already in progress, skipping")
+		return
+	}
+	defer e.mu.Unlock()
+
+	repos, err := e.store.MissingActivity(e.ghClient.Name(), e.batchSize)
+	if err != nil {
+		log.Printf("Error loading repos without activity: %v", err)
+		return
+	}
+
+	done, pending := 0, 0
+	for _, repo := range repos {
+		weekly, contributors, ready, err := e.ghClient.FetchCommitActivity(ctx, repo.FullName)
+		if err != nil {
+			var rlErr *github.RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				log.Printf("Activity enrichment stopped early: %v", err)
+				break
+			}
+			log.Printf("Error fetching activity for %s: %v", repo.FullName, err)
+			continue
+		}
+		if !ready {
+			// GitHub is computing the stats; the next run picks them up.
+			pending++
+			continue
+		}
+		activity := models.NewActivity(weekly, contributors, repo.PushedAt)
+		if err := e.store.SaveActivity(repo.Source, repo.ID, activity); err != nil {
+			log.Printf("Error storing activity for %s: %v", repo.FullName, err)
+			continue
+		}
+		done++
+	}
+	if len(repos) > 0 {
+		log.Printf("Enriched activity for %d/%d repos (%d still being computed by GitHub)", done, len(repos), pending)
+	}
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/ahmetburakdinc/codefossils/internal/cluster"
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// Clusterer regroups every listed repo into idea clusters of near-duplicates
+// from scratch, so clusters follow new repos, edited descriptions and
+// changed scores, which decide each cluster's representative.
+type Clusterer struct {
+	store     *database.ClusterStore
+	threshold float64
+	mu        sync.Mutex
+}
+
+func NewClusterer(store *database.ClusterStore, threshold float64) *Clusterer {
+	return &Clusterer{store: store, threshold: threshold}
+}
+
+func (c *Clusterer) Run(ctx context.Context) {
+	if !c.mu.TryLock() {
+		log.Println("Clustering already in progress, skipping")
+		return
+	}
+	defer c.mu.Unlock()
+
+	repos, err := c.store.Clusterable()
+	if err != nil {
+		log.Printf("Error loading repos to cluster: %v", err)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	groups := cluster.Group(repos, c.threshold)
+	clusters := make([]models.Cluster, len(groups))
+	clustered := 0
+	for i, group := range groups {
+		members := make([]models.Repo, len(group))
+		for j, k := range group {
+			members[j] = repos[k]
+		}
+		clusters[i] = models.Cluster{Terms: cluster.Terms(members), Size: len(members), Repos: members}
+		clustered += len(members)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if err := c.store.Save(clusters); err != nil {
+		log.Printf("Error saving clusters: %v", err)
+		return
+	}
+	log.Printf("Grouped %d of %d repos into %d idea clusters", clustered, len(repos), len(clusters))
+}
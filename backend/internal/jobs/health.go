@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/github"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// HealthEnricher looks for tests and CI in stored GitHub repos and reads
+// their build status, then updates their revivability score.
+type HealthEnricher struct {
+	store     *database.RepoStore
+	ghClient  *github.Client
+	evaluator models.Evaluator
+	batchSize int
+	mu        sync.Mutex
+}
+
+func NewHealthEnricher(store *database.RepoStore, ghClient *github.Client, evaluator models.Evaluator, batchSize int) *HealthEnricher {
+	return &HealthEnricher{
+		store:     store,
+		ghClient:  ghClient,
+		evaluator: evaluator,
+		batchSize: batchSize,
+	}
+}
+
+func (e *HealthEnricher) Run(ctx context.Context) {
+	if !e.mu.TryLock() {
+		log.Println("Health enrichment already in progress, skipping")
+		return
+	}
+	defer e.mu.Unlock()
+
+	repos, err := e.store.MissingHealth(e.ghClient.Name(), e.batchSize)
+	if err != nil {
+		log.Printf("Error loading repos without health: %v", err)
+		return
+	}
+
+	done := 0
+	for _, repo := range repos {
+		if err := e.ghClient.LoadHealth(ctx, &repo); err != nil {
+			var rlErr *github.RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				log.Printf("Health enrichment stopped early: %v", err)
+				break
+			}
+			log.Printf("Error checking health of %s: %v", repo.FullName, err)
+			continue
+		}
+		repo.Evaluate(e.evaluator)
+		if err := e.store.Upsert(repo); err != nil {
+			log.Printf("Error storing health for %s: %v", repo.FullName, err)
+			continue
+		}
+		done++
+	}
+	if len(repos) > 0 {
+		log.Printf("Checked health of %d/%d repos", done, len(repos))
+	}
+}
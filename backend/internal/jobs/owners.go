@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/github"
+)
+
+// ownerRecheckAge is how long an owner's enrichment is trusted before it is
+// fetched again. People come and go more slowly than repos change.
+const ownerRecheckAge = 30 * 24 * time.Hour
+
+// ownerRetryAge is how long an owner whose enrichment failed waits before it
+// is tried again.
+const ownerRetryAge = 24 * time.Hour
+
+// OwnerEnricher records, for the owners of stored GitHub fossils, whether
+// they are a user or an org, how old the account is, when they were last
+// active and how many of their other repos have gone stale.
+type OwnerEnricher struct {
+	owners    *database.OwnerStore
+	ghClient  *github.Client
+	batchSize int
+	mu        sync.Mutex
+}
+
+func NewOwnerEnricher(owners *database.OwnerStore, ghClient *github.Client, batchSize int) *OwnerEnricher {
+	return &OwnerEnricher{
+		owners:    owners,
+		ghClient:  ghClient,
+		batchSize: batchSize,
+	}
+}
+
+func (e *OwnerEnricher) Run(ctx context.Context) {
+	if !e.mu.TryLock() {
+		log.Println("Owner enrichment already in progress, skipping")
+		return
+	}
+	defer e.mu.Unlock()
+
+	logins, err := e.owners.Due(e.ghClient.Name(), time.Now().Add(-ownerRecheckAge), time.Now().Add(-ownerRetryAge), e.batchSize)
+	if err != nil {
+		log.Printf("Error loading owners to enrich: %v", err)
+		return
+	}
+
+	active := 0
+	done := 0
+	for _, login := range logins {
+		owner, err := e.ghClient.FetchOwner(ctx, login)
+		if err != nil {
+			var rlErr *github.RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				log.Printf("Owner enrichment stopped early: %v", err)
+				break
+			}
+			log.Printf("Error fetching owner %s: %v", login, err)
+			if err := e.owners.MarkFailed(e.ghClient.Name(), login); err != nil {
+				log.Printf("Error recording failure for owner %s: %v", login, err)
+			}
+			continue
+		}
+		if err := e.owners.Save(owner); err != nil {
+			log.Printf("Error storing owner %s: %v", login, err)
+			continue
+		}
+		if owner.IsActive(time.Now()) {
+			active++
+		}
+		done++
+	}
+	if len(logins) > 0 {
+		log.Printf("Enriched %d/%d owners, %d still active", done, len(logins), active)
+	}
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/github"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// ReadmeBackfill fetches READMEs for stored GitHub repos that lack one,
+// such as rows ingested before README support, and rescores them.
+type ReadmeBackfill struct {
+	store     *database.RepoStore
+	ghClient  *github.Client
+	evaluator models.Evaluator
+	batchSize int
+	mu        sync.Mutex
+}
+
+func NewReadmeBackfill(store *database.RepoStore, ghClient *github.Client, evaluator models.Evaluator, batchSize int) *ReadmeBackfill {
+	return &ReadmeBackfill{
+		store:     store,
+		ghClient:  ghClient,
+		evaluator: evaluator,
+		batchSize: batchSize,
+	}
+}
+
+func (b *ReadmeBackfill) Run(ctx context.Context) {
+	if !b.mu.TryLock() {
+		log.Println("README backfill already in progress, skipping")
+		return
+	}
+	defer b.mu.Unlock()
+
+	repos, err := b.store.MissingReadmes(b.ghClient.Name(), b.batchSize)
+	if err != nil {
+		log.Printf("Error loading repos without README: %v", err)
+		return
+	}
+
+	done := 0
+	for _, repo := range repos {
+		if err := b.ghClient.LoadReadme(ctx, &repo); err != nil {
+			var rlErr *github.RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				log.Printf("README backfill stopped early: %v", err)
+				break
+			}
+			log.Printf("Error fetching README for %s: %v", repo.FullName, err)
+			continue
+		}
+		repo.Evaluate(b.evaluator)
+		if err := b.store.Upsert(repo); err != nil {
+			log.Printf("Error storing README for %s: %v", repo.FullName, err)
+			continue
+		}
+		done++
+	}
+	if len(repos) > 0 {
+		log.Printf("Backfilled READMEs for %d/%d repos", done, len(repos))
+	}
+}
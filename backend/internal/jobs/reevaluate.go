@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// reevaluateBatch is how many repos are re-evaluated per transaction.
+const reevaluateBatch = 500
+
+// Reevaluator re-runs Repo.Evaluate on every stored repo scored or
+// categorized under a version other than the active scorer's or
+// categorizer's, or missing something Evaluate now derives, such as a score
+// breakdown. It works from stored data only, so a scoring or
+// categorization change takes effect without re-crawling.
+//
+// Scores also depend on how long ago a repo died, which changes without any
+// version changing, so repos evaluated more than maxAge ago are re-evaluated
+// as well.
+type Reevaluator struct {
+	store     *database.RepoStore
+	evaluator models.Evaluator
+	maxAge    time.Duration
+	mu        sync.Mutex
+	rerun     atomic.Bool // set by runs skipped while one was in progress
+}
+
+func NewReevaluator(store *database.RepoStore, evaluator models.Evaluator, maxAge time.Duration) *Reevaluator {
+	return &Reevaluator{store: store, evaluator: evaluator, maxAge: maxAge}
+}
+
+func (e *Reevaluator) Run(ctx context.Context) {
+	if !e.mu.TryLock() {
+		// Versions are read per batch, so the run in progress picks up the
+		// change that triggered this one; it only has to look once more
+		// before it stops.
+		e.rerun.Store(true)
+		log.Println("Re-evaluation already in progress, skipping")
+		return
+	}
+	defer e.mu.Unlock()
+
+	var scoreVersion, categoryVersion string
+	evaluatedBefore := time.Now().Add(-e.maxAge)
+	done := 0
+	for ctx.Err() == nil {
+		// The categorizer's rules can change while this runs.
+		scoreVersion = e.evaluator.Scorer.Version()
+		categoryVersion = e.evaluator.Categorizer.Version()
+		repos, err := e.store.Unevaluated(scoreVersion, categoryVersion, evaluatedBefore, reevaluateBatch)
+		if err != nil {
+			log.Printf("Error loading repos to re-evaluate: %v", err)
+			break
+		}
+		if len(repos) == 0 {
+			if e.rerun.Swap(false) {
+				continue
+			}
+			break
+		}
+		for i := range repos {
+			repos[i].Evaluate(e.evaluator)
+		}
+		if err := e.store.SaveEvaluations(repos); err != nil {
+			log.Printf("Error saving evaluations: %v", err)
+			break
+		}
+		done += len(repos)
+	}
+	if done > 0 {
+		log.Printf("Re-evaluated %d repos with %s and %s", done, scoreVersion, categoryVersion)
+	}
+}
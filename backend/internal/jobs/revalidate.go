@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/github"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// revivedWithin is how recent a new push must be for a fossil to count as
+// revived rather than just touched once.
+const revivedWithin = 365 * 24 * time.Hour
+
+// Revalidator re-fetches stored GitHub fossils by ID and updates their
+// lifecycle status: revived, renamed, archived or deleted.
+type Revalidator struct {
+	store      *database.RepoStore
+	ghClient   *github.Client
+	evaluator  models.Evaluator
+	batchSize  int
+	recheckAge time.Duration
+	mu         sync.Mutex
+}
+
+func NewRevalidator(store *database.RepoStore, ghClient *github.Client, evaluator models.Evaluator, batchSize int, recheckAge time.Duration) *Revalidator {
+	return &Revalidator{
+		store:      store,
+		ghClient:   ghClient,
+		evaluator:  evaluator,
+		batchSize:  batchSize,
+		recheckAge: recheckAge,
+	}
+}
+
+// Run checks one batch of the least recently checked repos.
+func (v *Revalidator) Run(ctx context.Context) {
+	if !v.mu.TryLock() {
+		log.Println("Revalidation already in progress, skipping")
+		return
+	}
+	defer v.mu.Unlock()
+
+	repos, err := v.store.DueForRevalidation(v.ghClient.Name(), time.Now().Add(-v.recheckAge), v.batchSize)
+	if err != nil {
+		log.Printf("Error loading repos to revalidate: %v", err)
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, stored := range repos {
+		fresh, found, err := v.ghClient.FetchRepoByID(ctx, stored.ID)
+		if err != nil {
+			var rlErr *github.RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				log.Printf("Revalidation stopped early: %v", err)
+				break
+			}
+			log.Printf("Error revalidating repo %d (%s): %v", stored.ID, stored.FullName, err)
+			// Keep the status but count the attempt, so the repo is retried
+			// after recheckAge instead of heading every batch.
+			if err := v.store.MarkChecked(stored.Source, stored.ID, stored.LifecycleStatus()); err != nil {
+				log.Printf("Error marking repo %d checked: %v", stored.ID, err)
+			}
+			continue
+		}
+
+		if !found {
+			counts[models.StatusDeleted]++
+			if err := v.store.MarkChecked(stored.Source, stored.ID, models.StatusDeleted); err != nil {
+				log.Printf("Error marking repo %d deleted: %v", stored.ID, err)
+			}
+			continue
+		}
+
+		now := time.Now()
+		fresh.ReadmeExcerpt = stored.ReadmeExcerpt
+		fresh.Stack = stored.Stack
+		fresh.DependenciesUpdatedAt = stored.DependenciesUpdatedAt
+		fresh.Health = stored.Health
+		fresh.Evaluate(v.evaluator)
+		fresh.Status = lifecycleStatus(stored, fresh)
+		fresh.CheckedAt = &now
+		counts[fresh.Status]++
+		if err := v.store.Upsert(fresh); err != nil {
+			log.Printf("Error updating repo %d: %v", stored.ID, err)
+		}
+	}
+
+	log.Printf("Revalidated %d repos: %d fossil, %d archived, %d renamed, %d revived, %d deleted",
+		len(repos), counts[models.StatusFossil], counts[models.StatusArchived],
+		counts[models.StatusRenamed], counts[models.StatusRevived], counts[models.StatusDeleted])
+}
+
+// lifecycleStatus compares the stored row with GitHub's current view. A
+// revival outranks an archive or rename, since it means the repo is no
+// longer a fossil at all.
+func lifecycleStatus(stored, fresh models.Repo) string {
+	switch {
+	case (fresh.PushedAt.After(stored.PushedAt) || stored.Status == models.StatusRevived) &&
+		time.Since(fresh.PushedAt) < revivedWithin:
+		return models.StatusRevived
+	case fresh.Archived:
+		return models.StatusArchived
+	case !strings.EqualFold(fresh.FullName, stored.FullName), stored.Status == models.StatusRenamed:
+		return models.StatusRenamed
+	default:
+		return models.StatusFossil
+	}
+}
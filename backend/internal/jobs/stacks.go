@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/ahmetburakdinc/codefossils/internal/database"
+	"github.com/ahmetburakdinc/codefossils/internal/github"
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// StackBackfill reads the dependency manifests of stored GitHub repos whose
+// stack is unknown, such as rows ingested before stack detection, and
+// recategorizes them.
+type StackBackfill struct {
+	store     *database.RepoStore
+	ghClient  *github.Client
+	evaluator models.Evaluator
+	batchSize int
+	mu        sync.Mutex
+}
+
+func NewStackBackfill(store *database.RepoStore, ghClient *github.Client, evaluator models.Evaluator, batchSize int) *StackBackfill {
+	return &StackBackfill{
+		store:     store,
+		ghClient:  ghClient,
+		evaluator: evaluator,
+		batchSize: batchSize,
+	}
+}
+
+func (b *StackBackfill) Run(ctx context.Context) {
+	if !b.mu.TryLock() {
+		log.Println("Stack backfill already in progress, skipping")
+		return
+	}
+	defer b.mu.Unlock()
+
+	repos, err := b.store.MissingStacks(b.ghClient.Name(), b.batchSize)
+	if err != nil {
+		log.Printf("Error loading repos without stack: %v", err)
+		return
+	}
+
+	done := 0
+	for _, repo := range repos {
+		if err := b.ghClient.LoadStack(ctx, &repo); err != nil {
+			var rlErr *github.RateLimitError
+			if errors.As(err, &rlErr) || ctx.Err() != nil {
+				log.Printf("Stack backfill stopped early: %v", err)
+				break
+			}
+			log.Printf("Error reading manifests for %s: %v", repo.FullName, err)
+			if err := b.store.MarkStackFailed(repo.SourceName(), repo.ID); err != nil {
+				log.Printf("Error recording stack failure for %s: %v", repo.FullName, err)
+			}
+			continue
+		}
+		repo.Evaluate(b.evaluator)
+		if err := b.store.Upsert(repo); err != nil {
+			log.Printf("Error storing stack for %s: %v", repo.FullName, err)
+			continue
+		}
+		done++
+	}
+	if len(repos) > 0 {
+		log.Printf("Backfilled stacks for %d/%d repos", done, len(repos))
+	}
+}
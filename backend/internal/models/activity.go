@@ -0,0 +1,54 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+const weekDuration = 7 * 24 * time.Hour
+
+// Activity is a fossil's commit history condensed into a weekly series plus
+// the figures that describe how it died.
+type Activity struct {
+	Start             time.Time // week of the first commit
+	Weeks             []int     // commits per week from Start to the last active week
+	Contributors      int
+	PeakWeek          time.Time
+	PeakCommits       int
+	MonthsPeakToDeath float64 // months between the busiest week and the last push
+}
+
+// NewActivity condenses weekly commit totals (keyed by the week's Unix start
+// time) into an Activity. Leading and trailing idle weeks are trimmed.
+func NewActivity(weekly map[int64]int, contributors int, pushedAt time.Time) Activity {
+	a := Activity{Contributors: contributors}
+
+	var active []int64
+	for week, commits := range weekly {
+		if commits > 0 {
+			active = append(active, week)
+		}
+	}
+	if len(active) == 0 {
+		return a
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i] < active[j] })
+
+	a.Start = time.Unix(active[0], 0).UTC()
+	last := time.Unix(active[len(active)-1], 0).UTC()
+	a.Weeks = make([]int, int(last.Sub(a.Start)/weekDuration)+1)
+	for _, week := range active {
+		i := int(time.Unix(week, 0).UTC().Sub(a.Start) / weekDuration)
+		a.Weeks[i] = weekly[week]
+		// On ties the later week wins: the last burst is closer to the death.
+		if weekly[week] >= a.PeakCommits {
+			a.PeakCommits = weekly[week]
+			a.PeakWeek = a.Start.Add(time.Duration(i) * weekDuration)
+		}
+	}
+
+	if pushedAt.After(a.PeakWeek) {
+		a.MonthsPeakToDeath = pushedAt.Sub(a.PeakWeek).Hours() / 24 / 30.44
+	}
+	return a
+}
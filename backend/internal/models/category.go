@@ -0,0 +1,506 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Category is one entry of the taxonomy repos are sorted into, with the
+// rules the keyword categorizer applies.
+type Category struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Icon        string `json:"icon"`
+	Description string `json:"description"`
+	// SortOrder orders categories among their siblings in the UI and breaks
+	// ties between them when
+	// categorizing, so more specific categories come first: a React Native
+	// app also depends on React, and a model training repo on pandas.
+	SortOrder int    `json:"sort_order"`
+	Parent    string `json:"parent,omitempty"`
+	// Keywords are regular expressions matched as whole words against a
+	// repo's lowercased name, description, topics and language. Stack lists
+	// the stack entries that imply the category, which is firmer evidence
+	// than the words in a description.
+	Keywords  []string  `json:"keywords"`
+	Stack     []string  `json:"stack"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CategoryOther is the category of repos nothing else fits. It has no rules
+// and can't be deleted.
+const CategoryOther = "other"
+
+// CategoryLabel is one category a repo belongs to and how confident we are
+// that it does, 0..1.
+type CategoryLabel struct {
+	ID         string  `json:"id"`
+	Confidence float64 `json:"confidence"`
+}
+
+// LabeledRepo is a repo whose categories were assigned by hand, primary
+// first, to train and evaluate classifiers on.
+type LabeledRepo struct {
+	Repo       Repo
+	Categories []string
+}
+
+// DefaultCategories are the categories the taxonomy is seeded with, parents
+// before their subcategories. Each is added once; from then on the taxonomy
+// is edited through the admin API.
+func DefaultCategories() []Category {
+	return []Category{
+		{
+			ID: "mobile", Label: "Mobile", Icon: "\U0001F4F1", SortOrder: 10,
+			Description: "iOS, Android, React Native",
+			Keywords:    []string{"ios", "android", "flutter", `react.native`, "swift", "kotlin", "mobile"},
+			Stack:       []string{"react-native", "expo", "ionic", "capacitor", "nativescript", "android", "kivy"},
+		},
+		{
+			ID: "mobile/ios", Parent: "mobile", Label: "iOS", Icon: "\U0001F34F", SortOrder: 10,
+			Description: "iPhone and iPad apps",
+			Keywords:    []string{"ios", "iphone", "ipad", "swiftui", "uikit", "xcode"},
+		},
+		{
+			ID: "mobile/android", Parent: "mobile", Label: "Android", Icon: "\U0001F916", SortOrder: 20,
+			Description: "Android apps",
+			Keywords:    []string{"android", "apk", "jetpack"},
+			Stack:       []string{"android"},
+		},
+		{
+			ID: "game", Label: "Games", Icon: "\U0001F3AE", SortOrder: 20,
+			Description: "game engines, simulations, fun projects",
+			Keywords:    []string{"game", "unity", "godot", "phaser", "rpg", "puzzle", "arcade", "gameplay"},
+			Stack: []string{
+				"bevy", "macroquad", "ggez", "amethyst", "pygame", "arcade", "pyglet", "phaser",
+				"pixi.js", "babylon.js", "kaboom", "ebiten", "libgdx", "lwjgl", "gosu",
+				"minecraft-mod", "minecraft-plugin",
+			},
+		},
+		{
+			ID: "ai", Label: "AI / ML", Icon: "\U0001F9E0", SortOrder: 30,
+			Description: "machine learning, NLP, data science",
+			Keywords: []string{
+				`machine.learning`, `deep.learning`, "neural", "nlp", "gpt", "llm", "ai", "ml",
+				"tensorflow", "pytorch", "model", "transformer", "diffusion",
+			},
+			Stack: []string{
+				"pytorch", "tensorflow", "keras", "jax", "transformers", "scikit-learn", "xgboost",
+				"lightgbm", "openai", "langchain", "spacy", "nltk", "diffusers", "candle", "burn",
+				"deeplearning4j", "brain.js", "gradio",
+			},
+		},
+		{
+			ID: "ai/nlp", Parent: "ai", Label: "Language", Icon: "\U0001F4AC", SortOrder: 10,
+			Description: "NLP, chatbots, LLM apps",
+			Keywords: []string{
+				"nlp", `natural.language`, "chatbot", "llm", "gpt", "sentiment", `text.classification`,
+				"tokenizer", `speech.recognition`,
+			},
+			Stack: []string{"openai", "langchain", "spacy", "nltk"},
+		},
+		{
+			ID: "ai/vision", Parent: "ai", Label: "Vision", Icon: "\U0001F441\uFE0F", SortOrder: 20,
+			Description: "computer vision, image recognition, OCR",
+			Keywords: []string{
+				`computer.vision`, `image.(?:recognition|classification|segmentation)`,
+				`object.detection`, `face.(?:recognition|detection)`, "yolo", "ocr", "opencv",
+			},
+			Stack: []string{"opencv"},
+		},
+		{
+			ID: "security", Label: "Security", Icon: "\U0001F6E1\uFE0F", SortOrder: 32,
+			Description: "pentesting, exploits, cryptography, privacy",
+			Keywords: []string{
+				"security", "pentest(?:ing)?", "exploits?", "vulnerabilit(?:y|ies)", "malware", "ctf",
+				"fuzz(?:er|ing)", "cryptography", "encryption", "firewall", "xss", `sql.injection`,
+				"osint", "forensics", "honeypot",
+			},
+		},
+		{
+			ID: "blockchain", Label: "Blockchain", Icon: "\u26D3\uFE0F", SortOrder: 34,
+			Description: "cryptocurrencies, smart contracts, web3",
+			Keywords: []string{
+				"blockchain", "ethereum", "bitcoin", "cryptocurrenc(?:y|ies)", "solidity",
+				`smart.contracts?`, "web3", "nft", "defi", "dapp", "solana",
+			},
+			Stack: []string{"web3", "ethers", "go-ethereum", "solana"},
+		},
+		{
+			ID: "embedded", Label: "Embedded / IoT", Icon: "\U0001F50C", SortOrder: 36,
+			Description: "microcontrollers, firmware, smart home",
+			Keywords: []string{
+				"embedded", "iot", "arduino", `raspberry.pi`, "esp32", "esp8266", "microcontrollers?",
+				"firmware", "mqtt", "stm32", `home.automation`,
+			},
+			Stack: []string{"cortex-m", "embedded-hal"},
+		},
+		{
+			ID: "education", Label: "Education", Icon: "\U0001F393", SortOrder: 37,
+			Description: "tutorials, courses, exercises",
+			Keywords: []string{
+				"tutorials?", "course", "coursework", "homework", "education(?:al)?", "teaching",
+				`for.beginners`, "exercises", "curriculum", "lessons", `study.notes`,
+			},
+		},
+		{
+			ID: "productivity", Label: "Productivity", Icon: "\u2705", SortOrder: 38,
+			Description: "todo lists, notes, calendars, time tracking",
+			Keywords: []string{
+				"productivity", "todo", `to.do`, `task.manager`, `note.taking`, "notes", "pomodoro",
+				"calendar", "habits?", `time.track(?:er|ing)`, "bookmarks?", "kanban", "planner",
+				"reminders?",
+			},
+		},
+		{
+			ID: "web", Label: "Web Apps", Icon: "\U0001F310", SortOrder: 40,
+			Description: "web frameworks, sites, frontends",
+			Keywords: []string{
+				"react", "vue", "angular", "svelte", "next", "nuxt", `web\s?app`, "frontend",
+				"dashboard", "website", "html", "css", "django", "flask", "rails", "express",
+			},
+			Stack: []string{
+				"django", "flask", "fastapi", "tornado", "pyramid", "starlette", "rails", "sinatra",
+				"hanami", "react", "next.js", "vue", "nuxt", "angular", "svelte", "sveltekit", "solid",
+				"preact", "gatsby", "astro", "express", "koa", "fastify", "nestjs", "hapi", "gin",
+				"echo", "fiber", "chi", "gorilla-mux", "beego", "actix-web", "axum", "rocket", "warp",
+				"yew", "leptos", "spring-boot", "quarkus", "micronaut", "ktor", "jekyll", "middleman",
+			},
+		},
+		{
+			ID: "web/dashboard", Parent: "web", Label: "Dashboards", Icon: "\U0001F4C8", SortOrder: 10,
+			Description: "admin panels and dashboards",
+			Keywords:    []string{"dashboards?", `admin.(?:panel|dashboard|template)`, "backoffice"},
+		},
+		{
+			ID: "web/api", Parent: "web", Label: "APIs & Backends", Icon: "\u2699\uFE0F", SortOrder: 20,
+			Description: "REST and GraphQL services",
+			Keywords:    []string{`rest.?api`, "restful", "graphql", "backend", "microservices?", `api.server`},
+			Stack:       []string{"fastapi", "nestjs"},
+		},
+		{
+			ID: "web/site", Parent: "web", Label: "Sites & Blogs", Icon: "\U0001F4DD", SortOrder: 30,
+			Description: "static sites, blogs, portfolios",
+			Keywords:    []string{`static.site`, "blog", "portfolio", "jekyll", "hugo", `landing.page`},
+			Stack:       []string{"jekyll", "middleman", "gatsby", "astro"},
+		},
+		{
+			ID: "data", Label: "Data", Icon: "\U0001F4CA", SortOrder: 50,
+			Description: "databases, pipelines, visualization",
+			Keywords: []string{
+				"data", "analytics", "scraper", "crawler", "etl", "pipeline", "database",
+				"visualization", "chart",
+			},
+			Stack: []string{
+				"pandas", "polars", "spark", "airflow", "dbt", "scrapy", "beautifulsoup", "colly",
+				"d3", "plotly", "matplotlib", "seaborn", "streamlit", "dash", "kafka", "flink", "jupyter",
+			},
+		},
+		{
+			ID: "data/scraping", Parent: "data", Label: "Scraping", Icon: "\U0001F577\uFE0F", SortOrder: 10,
+			Description: "scrapers and crawlers",
+			Keywords:    []string{"scrap(?:er|ers|ing)", "crawlers?", "spiders?"},
+			Stack:       []string{"scrapy", "beautifulsoup", "colly", "cheerio", "jsoup", "nokogiri"},
+		},
+		{
+			ID: "data/visualization", Parent: "data", Label: "Visualization", Icon: "\U0001F4C9", SortOrder: 20,
+			Description: "charts, plots and graphs",
+			Keywords:    []string{"visuali[sz]ation", "charts?", "plot(?:s|ting)?"},
+			Stack:       []string{"d3", "plotly", "matplotlib", "seaborn", "chart.js", "echarts"},
+		},
+		{
+			ID: "dev-tools", Label: "Dev Tools", Icon: "\U0001F527", SortOrder: 60,
+			Description: "CLIs, libraries, build tools",
+			Keywords: []string{
+				"cli", "sdk", "api", "library", "framework", "plugin", "extension", "tool", "linter",
+				"compiler", "devtool", "package",
+			},
+			Stack: []string{
+				"cobra", "urfave-cli", "clap", "click", "typer", "commander", "yargs", "oclif",
+				"picocli", "thor", "vscode-extension", "terraform-provider", "bubbletea", "ratatui",
+			},
+		},
+		{
+			ID: "dev-tools/cli", Parent: "dev-tools", Label: "CLIs", Icon: "\u2328\uFE0F", SortOrder: 10,
+			Description: "command-line and terminal tools",
+			Keywords:    []string{"cli", `command.line`, "terminal", "tui", "shell"},
+			Stack: []string{
+				"cobra", "urfave-cli", "clap", "click", "typer", "commander", "yargs", "oclif",
+				"picocli", "thor", "inquirer", "bubbletea", "ratatui", "tview", "textual",
+			},
+		},
+		{
+			ID: "dev-tools/editor", Parent: "dev-tools", Label: "Editor Plugins", Icon: "\U0001F9E9", SortOrder: 20,
+			Description: "extensions for VS Code, Vim, Emacs and other editors",
+			Keywords:    []string{"vscode", `vs.code`, "vim", "neovim", "emacs", "sublime", "intellij", "jetbrains"},
+			Stack:       []string{"vscode-extension"},
+		},
+		{
+			ID: CategoryOther, Label: "Other", Icon: "\U0001F480", SortOrder: 1000,
+			Description: "everything else",
+		},
+	}
+}
+
+// stackShadows lists stack entries that are only there because of another
+// entry. A React Native app depends on React, but is no web app.
+var stackShadows = map[string][]string{
+	"react-native": {"react"},
+	"expo":         {"react"},
+	"ionic":        {"angular", "react", "vue"},
+	"capacitor":    {"angular", "react", "vue"},
+	"nativescript": {"angular", "vue"},
+}
+
+// reactNativeRe finds React Native in text, so the "react" in it doesn't
+// count as web.
+var reactNativeRe = regexp.MustCompile(`\breact[\s.-]?native\b`)
+
+// Confidences of the evidence for a category. The first category a repo's
+// stack implies, in taxonomy order, is near certain; further ones are likely
+// supporting libraries. Each keyword found closes half the remaining gap to
+// keywordConfidence.
+const (
+	primaryStackConfidence   = 0.9
+	secondaryStackConfidence = 0.6
+	keywordConfidence        = 0.8
+)
+
+// Taxonomy is a two-level tree of categories with their rules compiled.
+type Taxonomy struct {
+	categories []Category // each top-level category followed by its subcategories
+	byID       map[string]Category
+	children   map[string][]string
+	keywordRes map[string]*regexp.Regexp
+	stack      map[string]string // stack entry to category
+	version    string
+}
+
+// NewTaxonomy arranges categories into a tree and compiles their rules. A
+// subcategory's parent must be top-level, and its ID is the parent's
+// followed by a slash and a name of its own, as in "dev-tools/cli". NewTaxonomy
+// fails on categories that break these rules, keywords that aren't valid
+// regular expressions, and stack entries claimed by two categories other than
+// a parent and its subcategory, where the subcategory's claim wins.
+func NewTaxonomy(categories []Category) (*Taxonomy, error) {
+	t := &Taxonomy{
+		byID:       make(map[string]Category, len(categories)),
+		children:   make(map[string][]string),
+		keywordRes: make(map[string]*regexp.Regexp),
+		stack:      make(map[string]string),
+	}
+	for _, c := range categories {
+		if _, dup := t.byID[c.ID]; dup {
+			return nil, fmt.Errorf("category %s is listed twice", c.ID)
+		}
+		t.byID[c.ID] = c
+	}
+
+	var top []Category
+	subcategories := make(map[string][]Category)
+	for _, c := range categories {
+		if c.Parent == "" {
+			if strings.Contains(c.ID, "/") {
+				return nil, fmt.Errorf("top-level category %s can't have a slash in its id", c.ID)
+			}
+			top = append(top, c)
+			continue
+		}
+		parent, ok := t.byID[c.Parent]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("category %s has unknown parent %s", c.ID, c.Parent)
+		case parent.Parent != "":
+			return nil, fmt.Errorf("category %s has parent %s, which isn't top-level", c.ID, c.Parent)
+		case !strings.HasPrefix(c.ID, c.Parent+"/") || strings.Count(c.ID, "/") != 1:
+			return nil, fmt.Errorf("subcategory %s of %s must be named %s/<name>", c.ID, c.Parent, c.Parent)
+		}
+		subcategories[c.Parent] = append(subcategories[c.Parent], c)
+	}
+	sortCategories(top)
+	for _, parent := range top {
+		kids := subcategories[parent.ID]
+		sortCategories(kids)
+		t.categories = append(t.categories, parent)
+		t.categories = append(t.categories, kids...)
+		for _, k := range kids {
+			t.children[parent.ID] = append(t.children[parent.ID], k.ID)
+		}
+	}
+
+	// Only what changes which repos land where goes into the version.
+	type rules struct {
+		ID       string   `json:"id"`
+		Parent   string   `json:"parent,omitempty"`
+		Keywords []string `json:"keywords"`
+		Stack    []string `json:"stack"`
+	}
+	var versioned []rules
+
+	for _, c := range t.categories {
+		if len(c.Keywords) > 0 {
+			re, err := regexp.Compile(`\b(?:` + strings.Join(c.Keywords, "|") + `)\b`)
+			if err != nil {
+				return nil, fmt.Errorf("category %s has invalid keywords: %w", c.ID, err)
+			}
+			t.keywordRes[c.ID] = re
+		}
+		for _, s := range c.Stack {
+			// Parents come before their subcategories, so a subcategory
+			// always finds its parent's claim, never the other way round.
+			if other, ok := t.stack[s]; ok && other != c.Parent {
+				return nil, fmt.Errorf("stack entry %q is claimed by both %s and %s", s, other, c.ID)
+			}
+			t.stack[s] = c.ID
+		}
+		versioned = append(versioned, rules{c.ID, c.Parent, c.Keywords, c.Stack})
+	}
+
+	data, _ := json.Marshal(versioned)
+	sum := sha256.Sum256(data)
+	t.version = "keywords." + hex.EncodeToString(sum[:4])
+	return t, nil
+}
+
+// sortCategories sorts categories by sort order, then ID.
+func sortCategories(categories []Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		return a.ID < b.ID
+	})
+}
+
+// Version identifies the rules, so that editing them recategorizes stored
+// repos. Labels, icons and descriptions don't count.
+func (t *Taxonomy) Version() string { return t.version }
+
+// Categories returns every category, each top-level one followed by its
+// subcategories, in sort order.
+func (t *Taxonomy) Categories() []Category { return t.categories }
+
+// Has reports whether id is a category. CategoryOther always is.
+func (t *Taxonomy) Has(id string) bool {
+	_, ok := t.byID[id]
+	return ok || id == CategoryOther
+}
+
+// Subtree returns id followed by the IDs of its subcategories.
+func (t *Taxonomy) Subtree(id string) []string {
+	return append([]string{id}, t.children[id]...)
+}
+
+// CategorizeRepo returns every category the repo's stack and keywords in its
+// name, description, topics and language point to, most confident first.
+// Evidence for the same category from both sources adds up, and so does
+// evidence for a category's parent, which a subcategory replaces as the more
+// specific answer. A repo with no evidence for any category is
+// CategoryOther.
+func (t *Taxonomy) CategorizeRepo(name, description string, topics []string, language string, stack []string) []CategoryLabel {
+	confidence := make(map[string]float64)
+	add := func(cat string, c float64) {
+		// Independent evidence: the category holds unless every piece is wrong.
+		confidence[cat] = 1 - (1-confidence[cat])*(1-c)
+	}
+
+	shadowed := make(map[string]bool)
+	for _, s := range stack {
+		for _, dep := range stackShadows[s] {
+			shadowed[dep] = true
+		}
+	}
+	implied := make(map[string]bool)
+	for _, s := range stack {
+		if cat, ok := t.stack[s]; ok && !shadowed[s] {
+			implied[cat] = true
+		}
+	}
+	stackConfidence := primaryStackConfidence
+	for _, c := range t.categories {
+		if implied[c.ID] {
+			add(c.ID, stackConfidence)
+			stackConfidence = secondaryStackConfidence
+		}
+	}
+
+	text := strings.ToLower(name + " " + description + " " + strings.Join(topics, " ") + " " + language)
+	// "react_native" still matches mobile's react.native, but no longer \breact\b.
+	text = reactNativeRe.ReplaceAllString(text, "react_native")
+	for cat, re := range t.keywordRes {
+		found := make(map[string]bool)
+		for _, m := range re.FindAllString(text, -1) {
+			found[m] = true
+		}
+		if len(found) > 0 {
+			add(cat, keywordConfidence*(1-math.Pow(0.5, float64(len(found)))))
+		}
+	}
+
+	parents := make(map[string]float64)
+	for _, c := range t.categories {
+		if conf, ok := confidence[c.Parent]; ok && c.Parent != "" {
+			if _, found := confidence[c.ID]; found {
+				parents[c.Parent] = conf
+			}
+		}
+	}
+	for _, c := range t.categories {
+		if conf, ok := parents[c.Parent]; ok && c.Parent != "" {
+			if _, found := confidence[c.ID]; found {
+				add(c.ID, conf)
+			}
+		}
+	}
+	for id := range parents {
+		delete(confidence, id)
+	}
+
+	labels := make([]CategoryLabel, 0, len(confidence))
+	for _, c := range t.categories {
+		if conf, ok := confidence[c.ID]; ok {
+			labels = append(labels, CategoryLabel{ID: c.ID, Confidence: math.Round(conf*100) / 100})
+		}
+	}
+	if len(labels) == 0 {
+		return []CategoryLabel{{ID: CategoryOther, Confidence: 1}}
+	}
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].Confidence > labels[j].Confidence })
+	return labels
+}
+
+// KeywordCategorizer categorizes repos with a taxonomy's rules. The README
+// excerpt stands in for the description, which many fossils lack, and the
+// detected stack outweighs it. The taxonomy can be swapped while in use,
+// e.g. after an admin edits the categories.
+type KeywordCategorizer struct {
+	taxonomy atomic.Pointer[Taxonomy]
+}
+
+func NewKeywordCategorizer(t *Taxonomy) *KeywordCategorizer {
+	c := &KeywordCategorizer{}
+	c.taxonomy.Store(t)
+	return c
+}
+
+// Taxonomy returns the taxonomy in use.
+func (c *KeywordCategorizer) Taxonomy() *Taxonomy { return c.taxonomy.Load() }
+
+// SetTaxonomy replaces the taxonomy in use.
+func (c *KeywordCategorizer) SetTaxonomy(t *Taxonomy) { c.taxonomy.Store(t) }
+
+func (c *KeywordCategorizer) Version() string { return c.Taxonomy().Version() }
+
+func (c *KeywordCategorizer) Categorize(r Repo) []CategoryLabel {
+	return c.Taxonomy().CategorizeRepo(r.Name, r.Description+" "+r.ReadmeExcerpt, r.Topics, r.Language, r.Stack)
+}
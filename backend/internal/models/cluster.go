@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Cluster is a group of near-duplicate repos: attempts at the same idea, such
+// as the countless todo apps. The clustering job regroups repos periodically
+// and keeps a cluster's ID while most of its repos stay together.
+type Cluster struct {
+	ID int64 `json:"id"`
+	// Terms are the words most of the cluster's repos share, most common
+	// first, which sum up the idea.
+	Terms     []string  `json:"terms"`
+	Size      int       `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Repos are the cluster's repos, best first; the first is its
+	// representative, the one listings show when clusters are collapsed.
+	Repos []Repo `json:"repos"`
+}
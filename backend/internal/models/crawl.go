@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// CrawlWindow is one date slice of a discovery query in crawl mode. Windows
+// are narrowed until each matches at most 1000 results (GitHub's search cap),
+// and NextPage is the cursor a later run resumes from.
+type CrawlWindow struct {
+	ID          int64     `json:"id"`
+	QueryID     int64     `json:"query_id"`
+	PushedFrom  time.Time `json:"pushed_from"`
+	PushedTo    time.Time `json:"pushed_to"`
+	CreatedFrom time.Time `json:"created_from"`
+	CreatedTo   time.Time `json:"created_to"`
+	NextPage    int       `json:"next_page"`
+	TotalCount  int       `json:"total_count"` // -1 until the first page is fetched
+	Done        bool      `json:"done"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import "strings"
+
+// License classes, from most to least reusable. Permissive and copyleft code
+// may both be revived; copyleft just binds the revival to the same terms.
+const (
+	LicensePermissive = "permissive"
+	LicenseCopyleft   = "copyleft"
+	LicenseUnknown    = "unknown" // a license we can't classify, or none reported by the host
+	LicenseNone       = "none"    // no license: all rights reserved
+)
+
+// LicenseNoAssertion is the SPDX id for "not determined", which GitHub
+// reports for licenses it can't identify. Repos from sources that don't
+// report licenses have none stored instead (a nil Repo.License).
+const LicenseNoAssertion = "NOASSERTION"
+
+// LicenseClasses lists every class, as accepted by the license filter.
+var LicenseClasses = []string{LicensePermissive, LicenseCopyleft, LicenseUnknown, LicenseNone}
+
+// licenseClasses maps lowercased SPDX ids to their class. Hosts differ in
+// capitalisation (GitLab and Gitea keys are lowercase), hence the folding.
+var licenseClasses = map[string]string{
+	"mit": LicensePermissive, "mit-0": LicensePermissive, "apache-2.0": LicensePermissive,
+	"bsd-2-clause": LicensePermissive, "bsd-3-clause": LicensePermissive,
+	"bsd-3-clause-clear": LicensePermissive, "0bsd": LicensePermissive, "isc": LicensePermissive,
+	"unlicense": LicensePermissive, "cc0-1.0": LicensePermissive, "zlib": LicensePermissive,
+	"bsl-1.0": LicensePermissive, "wtfpl": LicensePermissive, "cc-by-4.0": LicensePermissive,
+	"artistic-2.0": LicensePermissive, "upl-1.0": LicensePermissive, "afl-3.0": LicensePermissive,
+	"ncsa": LicensePermissive, "postgresql": LicensePermissive, "python-2.0": LicensePermissive,
+	"ms-pl": LicensePermissive, "x11": LicensePermissive,
+
+	"gpl-2.0": LicenseCopyleft, "gpl-2.0-only": LicenseCopyleft, "gpl-2.0-or-later": LicenseCopyleft,
+	"gpl-3.0": LicenseCopyleft, "gpl-3.0-only": LicenseCopyleft, "gpl-3.0-or-later": LicenseCopyleft,
+	"agpl-3.0": LicenseCopyleft, "agpl-3.0-only": LicenseCopyleft, "agpl-3.0-or-later": LicenseCopyleft,
+	"lgpl-2.1": LicenseCopyleft, "lgpl-2.1-only": LicenseCopyleft, "lgpl-2.1-or-later": LicenseCopyleft,
+	"lgpl-3.0": LicenseCopyleft, "lgpl-3.0-only": LicenseCopyleft, "lgpl-3.0-or-later": LicenseCopyleft,
+	"mpl-2.0": LicenseCopyleft, "epl-1.0": LicenseCopyleft, "epl-2.0": LicenseCopyleft,
+	"eupl-1.1": LicenseCopyleft, "eupl-1.2": LicenseCopyleft, "osl-3.0": LicenseCopyleft,
+	"cc-by-sa-4.0": LicenseCopyleft, "ms-rl": LicenseCopyleft, "cecill-2.1": LicenseCopyleft,
+}
+
+// LicenseClass classifies an SPDX license id. An empty id means the host
+// reported no license, and a nil one that it didn't say.
+func LicenseClass(spdxID *string) string {
+	if spdxID == nil {
+		return LicenseUnknown
+	}
+	if *spdxID == "" {
+		return LicenseNone
+	}
+	if class, ok := licenseClasses[strings.ToLower(*spdxID)]; ok {
+		return class
+	}
+	return LicenseUnknown
+}
+
+// LicenseIDs returns the lowercased SPDX ids in a class, for classifying
+// stored rows in SQL.
+func LicenseIDs(class string) []string {
+	var ids []string
+	for id, c := range licenseClasses {
+		if c == class {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
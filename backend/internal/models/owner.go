@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// OwnerActiveWithin is how recent an owner's last public activity must be
+// for them to count as still around.
+const OwnerActiveWithin = 180 * 24 * time.Hour
+
+// Owner types.
+const (
+	OwnerUser    = "user"
+	OwnerOrg     = "org"
+	OwnerDeleted = "deleted" // the account no longer exists
+)
+
+// Owner describes the account a fossil belongs to, as found by the owner
+// enrichment job. Whether the author is still active elsewhere decides
+// whether there is anyone to ask about adopting the project.
+type Owner struct {
+	Source           string     `json:"-"`
+	Login            string     `json:"login"`
+	Type             string     `json:"type"`
+	AccountCreatedAt *time.Time `json:"account_created_at,omitempty"`
+	// LastActiveAt is the owner's most recent public event or push.
+	LastActiveAt *time.Time `json:"last_active_at,omitempty"`
+	// StaleRepos counts the owner's public repos, forks excluded, that
+	// haven't been pushed to in two years, this one included. Only the
+	// first thousand repos are counted.
+	StaleRepos int       `json:"stale_repos"`
+	EnrichedAt time.Time `json:"enriched_at"`
+	Active     bool      `json:"active"`
+}
+
+// IsActive reports whether the owner has been active within
+// OwnerActiveWithin of now.
+func (o Owner) IsActive(now time.Time) bool {
+	return o.LastActiveAt != nil && now.Sub(*o.LastActiveAt) < OwnerActiveWithin
+}
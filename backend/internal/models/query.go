@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// DiscoveryQuery is a stored search used to discover fossils. The query text
+// is combined with the staleness and star qualifiers at fetch time.
+type DiscoveryQuery struct {
+	ID             int64       `json:"id"`
+	Query          string      `json:"query"`
+	StaleAfterDays int         `json:"stale_after_days"`
+	MinStars       int         `json:"min_stars"`
+	SortOptions    []string    `json:"sort_options"`
+	Enabled        bool        `json:"enabled"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	Yield          *QueryYield `json:"yield,omitempty"`
+}
+
+// QueryRun records what one query produced during one refresh.
+type QueryRun struct {
+	ID           int64     `json:"id"`
+	QueryID      int64     `json:"query_id"`
+	RunAt        time.Time `json:"run_at"`
+	Fetched      int       `json:"fetched"`
+	NewRepos     int       `json:"new_repos"`
+	Duplicates   int       `json:"duplicates"`
+	AvgIdeaScore float64   `json:"avg_idea_score"`
+}
+
+// QueryYield aggregates a query's runs so weak queries are easy to spot.
+type QueryYield struct {
+	Runs         int        `json:"runs"`
+	Fetched      int        `json:"fetched"`
+	NewRepos     int        `json:"new_repos"`
+	Duplicates   int        `json:"duplicates"`
+	AvgIdeaScore float64    `json:"avg_idea_score"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+}
+
+// QueryResult is what a fetcher returned for one query. Duplicates counts
+// items dropped because an earlier query in the same run already returned them.
+type QueryResult struct {
+	Query      DiscoveryQuery
+	Repos      []Repo
+	Duplicates int
+}
@@ -1,29 +1,155 @@
 package models
 
 import (
-	"math"
 	"regexp"
 	"strings"
 	"time"
 )
 
 type Repo struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	FullName    string    `json:"full_name"`
-	OwnerLogin  string    `json:"owner_login"`
-	OwnerAvatar string    `json:"owner_avatar"`
-	HTMLURL     string    `json:"html_url"`
-	Description string    `json:"description"`
-	Language    string    `json:"language"`
-	Topics      []string  `json:"topics"`
-	Stargazers  int       `json:"stargazers_count"`
-	Forks       int       `json:"forks_count"`
-	PushedAt    time.Time `json:"pushed_at"`
-	CreatedAt   time.Time `json:"created_at"`
-	IdeaScore   int       `json:"idea_score"`
-	Category    string    `json:"category"`
-	FetchedAt   time.Time `json:"fetched_at"`
+	Source            string     `json:"source"` // hosting service; IDs are only unique within one
+	ID                int64      `json:"id"`
+	Name              string     `json:"name"`
+	FullName          string     `json:"full_name"`
+	OwnerLogin        string     `json:"owner_login"`
+	OwnerAvatar       string     `json:"owner_avatar"`
+	HTMLURL           string     `json:"html_url"`
+	Description       string     `json:"description"`
+	Language          string     `json:"language"`
+	Topics            []string   `json:"topics"`
+	Stargazers        int        `json:"stargazers_count"`
+	Forks             int        `json:"forks_count"`
+	PushedAt          time.Time  `json:"pushed_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	IdeaScore         int        `json:"idea_score"`
+	ScoreVersion      string     `json:"score_version"` // the Scorer version IdeaScore was computed with
+	Category          string     `json:"category"`
+	FetchedAt         time.Time  `json:"fetched_at"`
+	License           *string    `json:"license"`       // SPDX id; "" when the repo has none, nil when the host didn't say
+	LicenseClass      string     `json:"license_class"` // see LicenseClass
+	Archived          bool       `json:"archived"`
+	OpenIssues        int        `json:"open_issues_count"`
+	DiskUsageKB       int        `json:"disk_usage_kb"`
+	LastCommitAt      *time.Time `json:"last_commit_at,omitempty"`
+	LastCommitMessage string     `json:"last_commit_message,omitempty"`
+	Status            string     `json:"status"`
+	CheckedAt         *time.Time `json:"checked_at,omitempty"`
+
+	ReadmeExcerpt string   `json:"readme_excerpt,omitempty"`
+	ReadmeImages  []string `json:"readme_images,omitempty"`
+
+	// Readme holds the raw README markdown. GraphQL search returns it with
+	// the repo; otherwise it is fetched separately during ingestion.
+	Readme string `json:"-"`
+	// ReadmeFetchedAt is set once Readme, ReadmeExcerpt and ReadmeImages
+	// have been filled in; only then are they written to the database.
+	ReadmeFetchedAt *time.Time `json:"-"`
+
+	// Stack lists the frameworks and libraries found in the repo's
+	// dependency manifests, and DependenciesUpdatedAt when the most recently
+	// changed of them was last committed to; it is nil without manifests.
+	// StackFetchedAt is set once the manifests have been read; only then are
+	// Stack and DependenciesUpdatedAt written to the database.
+	Stack                 []string   `json:"stack"`
+	DependenciesUpdatedAt *time.Time `json:"dependencies_updated_at,omitempty"`
+	StackFetchedAt        *time.Time `json:"-"`
+
+	// Health is nil until the health enrichment job has looked at the repo.
+	// Only then is it written to the database.
+	Health *Health `json:"health,omitempty"`
+	// RevivabilityScore is how easy a revival would be; see Revivability.
+	RevivabilityScore int `json:"revivability_score"`
+
+	// Commit activity, filled in by the activity enrichment job. ActivityWeeks
+	// holds commits per week starting at ActivityStart; only single-repo
+	// lookups load it.
+	ActivityStart     *time.Time `json:"activity_start,omitempty"`
+	ActivityWeeks     []int      `json:"activity_weeks,omitempty"`
+	PeakWeek          *time.Time `json:"peak_week,omitempty"`
+	PeakCommits       int        `json:"peak_commits,omitempty"`
+	MonthsPeakToDeath *float64   `json:"months_peak_to_death,omitempty"`
+	Contributors      int        `json:"contributors,omitempty"`
+
+	// Figures derived from CreatedAt, PushedAt, Stargazers and
+	// DependenciesUpdatedAt by Derive. A fossil's last push is taken as its
+	// death.
+	LifespanMonths      float64  `json:"lifespan_months"`
+	StarsPerMonth       float64  `json:"stars_per_month"` // per month of lifespan, counting at least one
+	MonthsSinceDeath    float64  `json:"months_since_death"`
+	DependencyAgeMonths *float64 `json:"dependency_age_months,omitempty"` // nil while DependenciesUpdatedAt is
+
+	// Categories lists every category the repo belongs to, most confident
+	// first; Category is the first of them. CategoryVersion is the
+	// Categorizer version they were assigned with.
+	Categories      []CategoryLabel `json:"categories"`
+	CategoryVersion string          `json:"category_version"`
+
+	// ClusterID is the idea cluster of the repo's near-duplicates, if it has
+	// any, and ClusterSize counts the cluster's repos, this one included.
+	ClusterID   int64 `json:"cluster_id,omitempty"`
+	ClusterSize int   `json:"cluster_size,omitempty"`
+
+	// Owner is the owner's enrichment, when known. Only repo listings load it.
+	Owner *Owner `json:"owner,omitempty"`
+
+	// ScoreBreakdown explains IdeaScore. Only single-repo lookups and
+	// listings that ask for it load it.
+	ScoreBreakdown []ScoreComponent `json:"score_breakdown,omitempty"`
+}
+
+// Sources repos are ingested from.
+const (
+	SourceGitHub           = "github"
+	SourceGitHubEnterprise = "ghes"
+	SourceGitLab           = "gitlab"
+	SourceGitea            = "gitea" // Gitea and Forgejo, e.g. Codeberg; repos are stored under GiteaSource
+	SourceBitbucket        = "bitbucket"
+)
+
+// GiteaSource is the source a Gitea or Forgejo instance's repos are stored
+// under, e.g. gitea:codeberg.org, so two instances' IDs never collide.
+func GiteaSource(host string) string {
+	return SourceGitea + ":" + strings.ToLower(host)
+}
+
+var giteaSourceRe = regexp.MustCompile(`^gitea:[a-z0-9.-]+(:[0-9]+)?$`)
+
+// ValidSource reports whether s names a source repos are ingested from.
+func ValidSource(s string) bool {
+	switch s {
+	case SourceGitHub, SourceGitHubEnterprise, SourceGitLab, SourceBitbucket:
+		return true
+	}
+	return giteaSourceRe.MatchString(s)
+}
+
+// SourceName returns the repo's source, defaulting to GitHub.
+func (r Repo) SourceName() string {
+	if r.Source != "" {
+		return r.Source
+	}
+	return SourceGitHub
+}
+
+// Lifecycle statuses, maintained by the revalidation job.
+const (
+	StatusFossil   = "fossil"
+	StatusArchived = "archived"
+	StatusRenamed  = "renamed"
+	StatusRevived  = "revived"
+	StatusDeleted  = "deleted"
+)
+
+// LifecycleStatus returns the repo's status, deriving it from the archive
+// flag for freshly fetched repos that don't have one yet.
+func (r Repo) LifecycleStatus() string {
+	if r.Status != "" {
+		return r.Status
+	}
+	if r.Archived {
+		return StatusArchived
+	}
+	return StatusFossil
 }
 
 type RepoListResponse struct {
@@ -33,67 +159,94 @@ type RepoListResponse struct {
 	PerPage int    `json:"per_page"`
 }
 
+type ReadmeResponse struct {
+	Source  string   `json:"source"`
+	ID      int64    `json:"id"`
+	HTML    string   `json:"html"`
+	Excerpt string   `json:"excerpt"`
+	Images  []string `json:"images"`
+}
+
+// StatsResponse counts listed repos per category. Categories counts each
+// category's own labels; Tree is the taxonomy with each top-level count
+// rolled up over its subcategories.
 type StatsResponse struct {
-	Categories map[string]int `json:"categories"`
-	Total      int            `json:"total"`
+	Categories map[string]int  `json:"categories"`
+	Tree       []CategoryStats `json:"tree"`
+	Total      int             `json:"total"`
 }
 
-func ComputeIdeaScore(stars, forks int, description string, topics []string) int {
-	hasDesc := 0
-	if description != "" {
-		hasDesc = 1
-	}
-	descLen := len(description)
-	if descLen > 120 {
-		descLen = 120
-	}
-	hasTopics := 0
-	if len(topics) > 0 {
-		hasTopics = 1
-	}
+type CategoryStats struct {
+	ID       string          `json:"id"`
+	Label    string          `json:"label"`
+	Icon     string          `json:"icon"`
+	Count    int             `json:"count"`
+	Children []CategoryStats `json:"children,omitempty"`
+}
 
-	score := math.Log2(float64(stars)+1)*8 +
-		math.Log2(float64(forks)+1)*4 +
-		float64(hasDesc)*10 +
-		float64(descLen)/12.0 +
-		float64(hasTopics)*5
+// Scorer computes idea scores. Strategies live in package scoring.
+type Scorer interface {
+	// Name is the strategy's name, as selected by configuration.
+	Name() string
+	// Version identifies the strategy's formula and weights. Stored repos
+	// scored under another version are rescored.
+	Version() string
+	// Score returns the repo's idea score, 0..100, and the components it is
+	// the sum of before rounding and clamping.
+	Score(r Repo) (int, []ScoreComponent)
+}
 
-	rounded := int(math.Round(score))
-	if rounded > 100 {
-		return 100
-	}
-	return rounded
+// Categorizer assigns repos to categories. KeywordCategorizer applies the
+// taxonomy's rules; package classify has a trained one.
+type Categorizer interface {
+	// Version identifies the rules or model. Stored repos categorized under
+	// another version are recategorized.
+	Version() string
+	// Categorize returns every category the repo belongs to, most confident
+	// first. It returns at least one label, CategoryOther if nothing fits.
+	Categorize(r Repo) []CategoryLabel
 }
 
-var (
-	webRe      = regexp.MustCompile(`\b(react|vue|angular|svelte|next|nuxt|web\s?app|frontend|dashboard|website|html|css|django|flask|rails|express)\b`)
-	mobileRe   = regexp.MustCompile(`\b(ios|android|flutter|react.native|swift|kotlin|mobile)\b`)
-	aiRe       = regexp.MustCompile(`\b(machine.learning|deep.learning|neural|nlp|gpt|llm|ai|ml|tensorflow|pytorch|model|transformer|diffusion)\b`)
-	devToolsRe = regexp.MustCompile(`\b(cli|sdk|api|library|framework|plugin|extension|tool|linter|compiler|devtool|package)\b`)
-	dataRe     = regexp.MustCompile(`\b(data|analytics|scraper|crawler|etl|pipeline|database|visualization|chart)\b`)
-	gameRe     = regexp.MustCompile(`\b(game|unity|godot|phaser|rpg|puzzle|arcade|gameplay)\b`)
-)
+// Evaluator is what Evaluate scores and categorizes repos with.
+type Evaluator struct {
+	Scorer      Scorer
+	Categorizer Categorizer
+}
 
-func CategorizeRepo(name, description string, topics []string, language string) string {
-	text := strings.ToLower(name + " " + description + " " + strings.Join(topics, " ") + " " + language)
+// ScoreComponent is one term of an idea score: the input it looked at and
+// the points that earned. Yes/no inputs are 1 or 0.
+type ScoreComponent struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Points float64 `json:"points"`
+}
 
-	if webRe.MatchString(text) {
-		return "web"
-	}
-	if mobileRe.MatchString(text) {
-		return "mobile"
-	}
-	if aiRe.MatchString(text) {
-		return "ai"
-	}
-	if devToolsRe.MatchString(text) {
-		return "dev-tools"
-	}
-	if dataRe.MatchString(text) {
-		return "data"
-	}
-	if gameRe.MatchString(text) {
-		return "game"
+// averageMonth is the mean Gregorian month.
+const averageMonth = 2629746 * time.Second
+
+// Derive fills in LifespanMonths, StarsPerMonth, MonthsSinceDeath and
+// DependencyAgeMonths as of now.
+func (r *Repo) Derive(now time.Time) {
+	r.LifespanMonths = max(0, float64(r.PushedAt.Sub(r.CreatedAt))/float64(averageMonth))
+	r.StarsPerMonth = float64(r.Stargazers) / max(1, r.LifespanMonths)
+	r.MonthsSinceDeath = max(0, float64(now.Sub(r.PushedAt))/float64(averageMonth))
+	r.DependencyAgeMonths = nil
+	if r.DependenciesUpdatedAt != nil {
+		age := max(0, float64(now.Sub(*r.DependenciesUpdatedAt))/float64(averageMonth))
+		r.DependencyAgeMonths = &age
 	}
-	return "other"
+}
+
+// Evaluate fills in the derived figures (see Derive), LicenseClass,
+// IdeaScore, ScoreBreakdown, ScoreVersion, RevivabilityScore, Categories,
+// Category and CategoryVersion.
+func (r *Repo) Evaluate(ev Evaluator) {
+	r.Derive(time.Now())
+	r.LicenseClass = LicenseClass(r.License)
+	r.IdeaScore, r.ScoreBreakdown = ev.Scorer.Score(*r)
+	r.ScoreVersion = ev.Scorer.Version()
+	r.RevivabilityScore = Revivability(*r)
+	r.Categories = ev.Categorizer.Categorize(*r)
+	r.CategoryVersion = ev.Categorizer.Version()
+	r.Category = r.Categories[0].ID
 }
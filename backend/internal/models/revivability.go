@@ -0,0 +1,82 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Build statuses of a repo's default branch.
+const (
+	BuildPassing = "passing"
+	BuildFailing = "failing"
+	BuildUnknown = "unknown" // no CI, no runs, or runs still pending
+)
+
+// Health is what the health enrichment job found out about a repo's tests
+// and CI.
+type Health struct {
+	HasTests    bool      `json:"has_tests"`
+	HasCI       bool      `json:"has_ci"`
+	BuildStatus string    `json:"build_status"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// revivabilityLicense is what each license class contributes to the
+// revivability score: without a license there is nothing to revive legally.
+var revivabilityLicense = map[string]float64{
+	LicensePermissive: 25,
+	LicenseCopyleft:   18,
+	LicenseUnknown:    8,
+	LicenseNone:       0,
+}
+
+// Revivability scores, 0..100, how little work reviving a fossil would take,
+// as opposed to the idea score's how interesting it is:
+//
+//   - license: up to 25, see revivabilityLicense
+//   - tests: 15, and CI config: 10
+//   - a passing default branch build: 15
+//   - dependency age: 15 for dependency manifests last changed up to two
+//     years ago, fading to nothing at ten; the older the pinned versions,
+//     the more upgrading before anything builds
+//   - size: 10 up to 1 MB, fading to nothing at 1 GB
+//   - open issues: 10 with none, half at 20
+//
+// Inputs that aren't known, such as health before enrichment or sizes from
+// hosts that don't report them, earn half their points. Call Derive first.
+func Revivability(r Repo) int {
+	score := revivabilityLicense[r.LicenseClass]
+
+	if r.Health == nil {
+		score += (15 + 10 + 15) / 2.0
+	} else {
+		if r.Health.HasTests {
+			score += 15
+		}
+		if r.Health.HasCI {
+			score += 10
+		}
+		switch r.Health.BuildStatus {
+		case BuildPassing:
+			score += 15
+		case BuildUnknown:
+			score += 7.5
+		}
+	}
+
+	if r.DependencyAgeMonths != nil {
+		score += 15 * max(0, min((120-*r.DependencyAgeMonths)/96, 1))
+	} else {
+		score += 7.5
+	}
+
+	if r.DiskUsageKB > 0 {
+		score += 10 * max(0, min(1-math.Log10(float64(r.DiskUsageKB)/1000)/3, 1))
+	} else {
+		score += 5
+	}
+
+	score += 10 / (1 + float64(r.OpenIssues)/20)
+
+	return max(0, min(int(math.Round(score)), 100))
+}
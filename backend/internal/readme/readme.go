@@ -0,0 +1,437 @@
+// Package readme extracts and renders README markdown. Rendering is
+// sanitized by construction: all input text is escaped and only a fixed set
+// of tags, with checked http(s) URLs, is ever emitted.
+package readme
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	excerptMinLen = 40
+	excerptMaxLen = 300
+	maxImages     = 20
+)
+
+// destination matches a link or image URL. Like CommonMark it may hold
+// balanced parentheses, so javascript:alert(1) is matched, and rejected,
+// whole rather than leaving ")" behind.
+const destination = `(?:[^()\s]|\([^()\s]*\))+`
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockCode
+	blockQuote
+	blockList
+	blockTable
+	blockRule
+)
+
+type block struct {
+	kind    blockKind
+	level   int  // heading level
+	ordered bool // numbered list
+	lines   []string
+}
+
+var (
+	commentRe     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	scriptRe      = regexp.MustCompile(`(?is)<script\b.*?</script>|<style\b.*?</style>`)
+	headingRe     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	ruleRe        = regexp.MustCompile(`^([-*_])(\s*[-*_]){2,}$`)
+	setextH1Re    = regexp.MustCompile(`^=+$`)
+	setextH2Re    = regexp.MustCompile(`^-+$`)
+	bulletRe      = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	orderedRe     = regexp.MustCompile(`^\d{1,9}[.)]\s+(.*)$`)
+	tableSepRe    = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	imgTagRe      = regexp.MustCompile(`(?i)<img\s[^>]*>`)
+	srcAttrRe     = regexp.MustCompile(`(?i)\bsrc\s*=\s*["']([^"']+)["']`)
+	altAttrRe     = regexp.MustCompile(`(?i)\balt\s*=\s*["']([^"']*)["']`)
+	brTagRe       = regexp.MustCompile(`(?i)<br\s*/?>`)
+	tagRe         = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	codeSpanRe    = regexp.MustCompile("`([^`]+)`")
+	imageRe       = regexp.MustCompile(`!\[([^\]]*)\]\((` + destination + `)(?:\s+[^)]*)?\)`)
+	linkRe        = regexp.MustCompile(`\[([^\]]+)\]\((` + destination + `)(?:\s+[^)]*)?\)`)
+	autolinkRe    = regexp.MustCompile(`<(https?://[^\s>]+)>`)
+	boldRe        = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicStarRe  = regexp.MustCompile(`(^|[^\w*])\*([^*\s][^*]*)\*`)
+	italicUnderRe = regexp.MustCompile(`(^|[^\w])_([^_\s][^_]*)_([^\w]|$)`)
+	strikeRe      = regexp.MustCompile(`~~([^~]+)~~`)
+	placeholder   = regexp.MustCompile("\x00(\\d+)\x00")
+	spaceRe       = regexp.MustCompile(`\s+`)
+)
+
+// parse splits markdown into blocks. It covers the subset READMEs actually
+// use; anything it doesn't recognise ends up as paragraph text.
+func parse(md string) []block {
+	md = strings.ReplaceAll(md, "\x00", "")
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = commentRe.ReplaceAllString(md, "")
+	md = scriptRe.ReplaceAllString(md, "")
+	lines := strings.Split(md, "\n")
+
+	var blocks []block
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, block{kind: blockParagraph, lines: para})
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, block{kind: blockCode, lines: code})
+
+		case headingRe.MatchString(trimmed):
+			flush()
+			m := headingRe.FindStringSubmatch(trimmed)
+			blocks = append(blocks, block{kind: blockHeading, level: len(m[1]), lines: []string{m[2]}})
+
+		case len(para) > 0 && setextH1Re.MatchString(trimmed):
+			blocks = append(blocks, block{kind: blockHeading, level: 1, lines: []string{strings.Join(para, " ")}})
+			para = nil
+
+		case len(para) > 0 && setextH2Re.MatchString(trimmed):
+			blocks = append(blocks, block{kind: blockHeading, level: 2, lines: []string{strings.Join(para, " ")}})
+			para = nil
+
+		case ruleRe.MatchString(trimmed):
+			flush()
+			blocks = append(blocks, block{kind: blockRule})
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(q, " "))
+			}
+			i--
+			blocks = append(blocks, block{kind: blockQuote, lines: quote})
+
+		case bulletRe.MatchString(trimmed) || orderedRe.MatchString(trimmed):
+			flush()
+			b := block{kind: blockList, ordered: orderedRe.MatchString(trimmed)}
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if t == "" {
+					break
+				}
+				if m := bulletRe.FindStringSubmatch(t); m != nil {
+					b.lines = append(b.lines, m[1])
+				} else if m := orderedRe.FindStringSubmatch(t); m != nil {
+					b.lines = append(b.lines, m[1])
+				} else {
+					// Continuation of the previous item.
+					b.lines[len(b.lines)-1] += " " + t
+				}
+			}
+			i--
+			blocks = append(blocks, b)
+
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && tableSepRe.MatchString(strings.TrimSpace(lines[i+1])):
+			flush()
+			b := block{kind: blockTable, lines: []string{trimmed}}
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				b.lines = append(b.lines, strings.TrimSpace(lines[i]))
+			}
+			i--
+			blocks = append(blocks, b)
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// RenderHTML renders README markdown to sanitized HTML. Relative links and
+// images are resolved against repoURL (the repo's html_url).
+func RenderHTML(md, repoURL string) string {
+	r := renderer{repoURL: strings.TrimSuffix(repoURL, "/")}
+	var sb strings.Builder
+	r.render(&sb, parse(md))
+	return sb.String()
+}
+
+type renderer struct {
+	repoURL string
+}
+
+func (r renderer) render(sb *strings.Builder, blocks []block) {
+	for _, b := range blocks {
+		switch b.kind {
+		case blockParagraph:
+			text := r.inline(strings.Join(b.lines, " "))
+			if strings.TrimSpace(text) != "" {
+				sb.WriteString("<p>" + text + "</p>\n")
+			}
+		case blockHeading:
+			fmt.Fprintf(sb, "<h%d>%s</h%d>\n", b.level, r.inline(b.lines[0]), b.level)
+		case blockCode:
+			sb.WriteString("<pre><code>" + html.EscapeString(strings.Join(b.lines, "\n")) + "</code></pre>\n")
+		case blockQuote:
+			sb.WriteString("<blockquote>\n")
+			r.render(sb, parse(strings.Join(b.lines, "\n")))
+			sb.WriteString("</blockquote>\n")
+		case blockList:
+			tag := "ul"
+			if b.ordered {
+				tag = "ol"
+			}
+			sb.WriteString("<" + tag + ">\n")
+			for _, item := range b.lines {
+				sb.WriteString("<li>" + r.inline(item) + "</li>\n")
+			}
+			sb.WriteString("</" + tag + ">\n")
+		case blockTable:
+			sb.WriteString("<table>\n<thead><tr>")
+			for _, cell := range tableCells(b.lines[0]) {
+				sb.WriteString("<th>" + r.inline(cell) + "</th>")
+			}
+			sb.WriteString("</tr></thead>\n<tbody>\n")
+			for _, row := range b.lines[1:] {
+				sb.WriteString("<tr>")
+				for _, cell := range tableCells(row) {
+					sb.WriteString("<td>" + r.inline(cell) + "</td>")
+				}
+				sb.WriteString("</tr>\n")
+			}
+			sb.WriteString("</tbody>\n</table>\n")
+		case blockRule:
+			sb.WriteString("<hr>\n")
+		}
+	}
+}
+
+// inline renders span-level markdown. Raw HTML is dropped except <img>,
+// which is rewritten to markdown first so it goes through the same URL checks.
+// Rendered fragments are parked behind placeholders so later passes (and
+// emphasis in particular) never touch attribute values.
+func (r renderer) inline(s string) string {
+	var frags []string
+	hold := func(fragment string) string {
+		frags = append(frags, fragment)
+		return "\x00" + strconv.Itoa(len(frags)-1) + "\x00"
+	}
+
+	s = codeSpanRe.ReplaceAllStringFunc(s, func(m string) string {
+		return hold("<code>" + html.EscapeString(m[1:len(m)-1]) + "</code>")
+	})
+
+	s = imgTagRe.ReplaceAllStringFunc(s, imgTagToMarkdown)
+	s = autolinkRe.ReplaceAllString(s, "[$1]($1)")
+	s = brTagRe.ReplaceAllString(s, " ")
+	s = tagRe.ReplaceAllString(s, "")
+
+	s = html.EscapeString(s)
+
+	s = imageRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := imageRe.FindStringSubmatch(m)
+		src, ok := r.resolve(sub[2], true)
+		if !ok {
+			return sub[1]
+		}
+		return hold(`<img src="` + src + `" alt="` + sub[1] + `" loading="lazy">`)
+	})
+	s = linkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := linkRe.FindStringSubmatch(m)
+		href, ok := r.resolve(sub[2], false)
+		if !ok {
+			return sub[1]
+		}
+		return hold(`<a href="` + href + `" rel="nofollow noopener noreferrer" target="_blank">` + emphasis(sub[1]) + `</a>`)
+	})
+	s = emphasis(s)
+
+	// Link text may itself hold placeholders (badge images), so expand
+	// until none are left.
+	for placeholder.MatchString(s) {
+		s = placeholder.ReplaceAllStringFunc(s, func(m string) string {
+			i, _ := strconv.Atoi(strings.Trim(m, "\x00"))
+			return frags[i]
+		})
+	}
+	return s
+}
+
+func emphasis(s string) string {
+	s = boldRe.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = italicStarRe.ReplaceAllString(s, "$1<em>$2</em>")
+	s = italicUnderRe.ReplaceAllString(s, "$1<em>$2</em>$3")
+	return strikeRe.ReplaceAllString(s, "<del>$1</del>")
+}
+
+// resolve validates an (HTML-escaped) URL and returns it escaped for use in
+// an attribute. Only http(s), mailto and in-page anchors pass; relative paths
+// point into the repo.
+func (r renderer) resolve(escaped string, image bool) (string, bool) {
+	raw := html.UnescapeString(escaped)
+	if strings.HasPrefix(raw, "#") && !image {
+		return html.EscapeString(raw), true
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "mailto":
+		if image {
+			return "", false
+		}
+	case "":
+		if u.Host != "" {
+			u.Scheme = "https"
+			break
+		}
+		if r.repoURL == "" || u.Path == "" {
+			return "", false
+		}
+		kind := "blob"
+		if image {
+			kind = "raw"
+		}
+		path := strings.TrimPrefix(strings.TrimPrefix(u.Path, "./"), "/")
+		base, err := url.Parse(r.repoURL + "/" + kind + "/HEAD/")
+		if err != nil {
+			return "", false
+		}
+		u = base.ResolveReference(&url.URL{Path: path, RawQuery: u.RawQuery, Fragment: u.Fragment})
+	default:
+		return "", false
+	}
+	return html.EscapeString(u.String()), true
+}
+
+func imgTagToMarkdown(tag string) string {
+	src := srcAttrRe.FindStringSubmatch(tag)
+	if src == nil {
+		return ""
+	}
+	alt := ""
+	if m := altAttrRe.FindStringSubmatch(tag); m != nil {
+		alt = m[1]
+	}
+	return "![" + alt + "](" + src[1] + ")"
+}
+
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// Clean makes raw README bytes safe to store as text: invalid UTF-8, such as
+// a character cut in half by a size limit, and NUL bytes are dropped.
+func Clean(md string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(md, ""), "\x00", "")
+}
+
+// Excerpt returns the first real paragraph of prose as plain text, skipping
+// badge rows, logos and other short lines.
+func Excerpt(md string) string {
+	var fallback string
+	for _, b := range parse(md) {
+		if b.kind != blockParagraph {
+			continue
+		}
+		text := plainText(strings.Join(b.lines, " "))
+		if len(text) >= excerptMinLen {
+			return truncate(text, excerptMaxLen)
+		}
+		if fallback == "" {
+			fallback = text
+		}
+	}
+	return truncate(fallback, excerptMaxLen)
+}
+
+func plainText(s string) string {
+	s = imgTagRe.ReplaceAllString(s, "")
+	s = tagRe.ReplaceAllString(s, " ")
+	s = imageRe.ReplaceAllString(s, "")
+	s = linkRe.ReplaceAllString(s, "$1")
+	s = codeSpanRe.ReplaceAllString(s, "$1")
+	s = boldRe.ReplaceAllString(s, "$1$2")
+	s = strikeRe.ReplaceAllString(s, "$1")
+	s = strings.NewReplacer("*", "", "_", " ").Replace(s)
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := strings.LastIndex(s[:max], " ")
+	if cut < max/2 {
+		cut = max
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+	}
+	return strings.TrimSpace(s[:cut]) + "…"
+}
+
+// ImageURLs returns the absolute URLs of the images a README shows, in
+// order and without duplicates.
+func ImageURLs(md, repoURL string) []string {
+	r := renderer{repoURL: strings.TrimSuffix(repoURL, "/")}
+	seen := make(map[string]bool)
+	images := []string{}
+
+	var walk func([]block)
+	walk = func(blocks []block) {
+		for _, b := range blocks {
+			switch b.kind {
+			case blockCode, blockRule:
+				continue
+			case blockQuote:
+				walk(parse(strings.Join(b.lines, "\n")))
+				continue
+			}
+			text := imgTagRe.ReplaceAllStringFunc(strings.Join(b.lines, "\n"), imgTagToMarkdown)
+			for _, m := range imageRe.FindAllStringSubmatch(html.EscapeString(text), -1) {
+				src, ok := r.resolve(m[2], true)
+				if !ok {
+					continue
+				}
+				src = html.UnescapeString(src)
+				if !seen[src] && len(images) < maxImages {
+					seen[src] = true
+					images = append(images, src)
+				}
+			}
+		}
+	}
+	walk(parse(md))
+	return images
+}
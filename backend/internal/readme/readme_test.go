@@ -0,0 +1,97 @@
+package readme
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const repoURL = "https://github.com/someone/fossil"
+
+func TestRenderHTMLSanitizes(t *testing.T) {
+	tests := []struct {
+		name, md, want string
+	}{
+		{"script", "Hi<script>alert(1)</script> there", "<p>Hi there</p>\n"},
+		{"multi-line script", "<script>\nfetch('/x')\n</script>\n\nText", "<p>Text</p>\n"},
+		{"iframe", `<iframe src="https://evil.example/"></iframe>Text`, "<p>Text</p>\n"},
+		{"event handler", `<div onclick="alert(1)">Text</div>`, "<p>Text</p>\n"},
+		{"javascript link", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"javascript link, mixed case", "[x](JavaScript:alert(document.cookie))", "<p>x</p>\n"},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>\n"},
+		{"data image", "![pic](data:image/svg+xml;base64,PHN2Zz4=)", "<p>pic</p>\n"},
+		{"javascript img tag", `<img src="javascript:alert(1)" alt="pic">`, "<p>pic</p>\n"},
+		{"quote in url", `[x](https://example.com/"onmouseover="alert(1))`,
+			`<p><a href="https://example.com/%22onmouseover=%22alert%281%29" rel="nofollow noopener noreferrer" target="_blank">x</a></p>` + "\n"},
+		{"url with parentheses", "[Fossil](https://en.wikipedia.org/wiki/Fossil_(disambiguation))",
+			`<p><a href="https://en.wikipedia.org/wiki/Fossil_(disambiguation)" rel="nofollow noopener noreferrer" target="_blank">Fossil</a></p>` + "\n"},
+		{"code", "`<b>bold</b>`", "<p><code>&lt;b&gt;bold&lt;/b&gt;</code></p>\n"},
+	}
+	for _, tt := range tests {
+		if got := RenderHTML(tt.md, repoURL); got != tt.want {
+			t.Errorf("%s: RenderHTML(%q) = %q, want %q", tt.name, tt.md, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHTMLResolvesRelativeURLs(t *testing.T) {
+	tests := []struct {
+		md, want string
+	}{
+		{"![shot](docs/screen.png)", `<img src="https://github.com/someone/fossil/raw/HEAD/docs/screen.png" alt="shot" loading="lazy">`},
+		{"![shot](./screen.png?v=2)", `<img src="https://github.com/someone/fossil/raw/HEAD/screen.png?v=2" alt="shot" loading="lazy">`},
+		{`<img src="/img/logo.svg" alt="logo">`, `<img src="https://github.com/someone/fossil/raw/HEAD/img/logo.svg" alt="logo" loading="lazy">`},
+		{"![badge](//img.shields.io/badge/x-y-green)", `<img src="https://img.shields.io/badge/x-y-green" alt="badge" loading="lazy">`},
+		{"[guide](docs/GUIDE.md#setup)", `<a href="https://github.com/someone/fossil/blob/HEAD/docs/GUIDE.md#setup" rel="nofollow noopener noreferrer" target="_blank">guide</a>`},
+		{"[top](#usage)", `<a href="#usage" rel="nofollow noopener noreferrer" target="_blank">top</a>`},
+	}
+	for _, tt := range tests {
+		got := RenderHTML(tt.md, repoURL)
+		if want := "<p>" + tt.want + "</p>\n"; got != want {
+			t.Errorf("RenderHTML(%q) = %q, want %q", tt.md, got, want)
+		}
+	}
+}
+
+func TestImageURLs(t *testing.T) {
+	md := strings.Join([]string{
+		"![logo](logo.png) ![logo again](logo.png)",
+		`<img src="https://example.com/shot.gif">`,
+		"![bad](javascript:alert(1))",
+		"```",
+		"![in code](code.png)",
+		"```",
+		"> ![quoted](docs/quoted.png)",
+	}, "\n")
+	want := []string{
+		"https://github.com/someone/fossil/raw/HEAD/logo.png",
+		"https://example.com/shot.gif",
+		"https://github.com/someone/fossil/raw/HEAD/docs/quoted.png",
+	}
+	if got := ImageURLs(md, repoURL); !reflect.DeepEqual(got, want) {
+		t.Errorf("ImageURLs = %q, want %q", got, want)
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct{ md, want string }{
+		{"# Fossil\x00\n", "# Fossil\n"},
+		{"caf\xc3\xa9 na\xc3", "café na"}, // é, then half of another
+		{"\xff\xfeplain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.md); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.md, got, tt.want)
+		}
+	}
+}
+
+// TestExcerptKeepsRunesWhole checks that an excerpt cut without a space to
+// cut at doesn't split a multi-byte character.
+func TestExcerptKeepsRunesWhole(t *testing.T) {
+	got := Excerpt("a" + strings.Repeat("é", excerptMaxLen))
+	if !utf8.ValidString(got) || !strings.HasSuffix(got, "é…") {
+		t.Errorf("Excerpt = %q, want whole characters", got)
+	}
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"log"
 	"time"
 
@@ -12,6 +13,14 @@ type Scheduler struct {
 	handler  *handlers.RepoHandler
 	store    *database.RepoStore
 	interval time.Duration
+	jobs     []job
+}
+
+// job is a periodic background task that runs alongside the refresh.
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
 }
 
 func New(handler *handlers.RepoHandler, store *database.RepoStore, interval time.Duration) *Scheduler {
@@ -22,7 +31,14 @@ func New(handler *handlers.RepoHandler, store *database.RepoStore, interval time
 	}
 }
 
-func (s *Scheduler) Start() {
+// AddJob registers a task to run every interval once Start is called.
+func (s *Scheduler) AddJob(name string, interval time.Duration, run func(ctx context.Context)) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start runs the initial refresh if needed and then the periodic refresh and
+// jobs until ctx is cancelled. Runs in progress see the same ctx and stop.
+func (s *Scheduler) Start(ctx context.Context) {
 	// Check if DB is empty and do initial fetch
 	count, err := s.store.Count()
 	if err != nil {
@@ -31,7 +47,7 @@ func (s *Scheduler) Start() {
 
 	if count == 0 {
 		log.Println("Database is empty, triggering initial fetch...")
-		s.handler.DoRefreshSync()
+		s.handler.DoRefreshSync(ctx)
 	} else {
 		log.Printf("Database has %d repos, skipping initial fetch", count)
 	}
@@ -43,9 +59,32 @@ func (s *Scheduler) Start() {
 
 		log.Printf("Scheduler started: refreshing every %s", s.interval)
 
-		for range ticker.C {
-			log.Println("Scheduled refresh triggered")
-			s.handler.DoRefreshSync()
+		for {
+			select {
+			case <-ticker.C:
+				log.Println("Scheduled refresh triggered")
+				s.handler.DoRefreshSync(ctx)
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
+
+	for _, j := range s.jobs {
+		go func(j job) {
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+
+			log.Printf("Job %s scheduled every %s", j.name, j.interval)
+
+			for {
+				select {
+				case <-ticker.C:
+					j.run(ctx)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(j)
+	}
 }
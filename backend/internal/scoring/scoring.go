@@ -0,0 +1,119 @@
+// Package scoring holds the idea score strategies. Each strategy has a name,
+// a revision that is bumped whenever its formula changes, and default weights
+// that configuration may override. The version stored with each score is
+// derived from all three, so changing any of them marks stored scores stale.
+package scoring
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// Default is the strategy used when none is configured.
+const Default = "weighted"
+
+// Weights maps a strategy's weight names to their values.
+type Weights map[string]float64
+
+// strategy is a scoring formula over a set of named weights.
+type strategy struct {
+	revision int
+	defaults Weights
+	score    func(w Weights, r models.Repo) []models.ScoreComponent
+}
+
+// strategies lists every strategy by name.
+var strategies = map[string]strategy{
+	"weighted":   {revision: 2, defaults: weightedDefaults, score: weighted},
+	"popularity": {revision: 1, defaults: popularityDefaults, score: popularity},
+}
+
+// Names returns the strategy names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the named strategy with overrides applied to its default
+// weights. Weights the strategy doesn't have are rejected, so a typo doesn't
+// go unnoticed.
+func New(name string, overrides Weights) (models.Scorer, error) {
+	s, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scorer %q (have %s)", name, strings.Join(Names(), ", "))
+	}
+	weights := make(Weights, len(s.defaults))
+	for k, v := range s.defaults {
+		weights[k] = v
+	}
+	for k, v := range overrides {
+		if _, ok := s.defaults[k]; !ok {
+			return nil, fmt.Errorf("scorer %q has no weight %q", name, k)
+		}
+		weights[k] = v
+	}
+	return &scorer{
+		name:     name,
+		version:  version(name, s.revision, s.defaults, weights),
+		weights:  weights,
+		strategy: s,
+	}, nil
+}
+
+type scorer struct {
+	name     string
+	version  string
+	weights  Weights
+	strategy strategy
+}
+
+func (s *scorer) Name() string    { return s.name }
+func (s *scorer) Version() string { return s.version }
+
+// Score sums the strategy's components, rounded and clamped to 0..100.
+func (s *scorer) Score(r models.Repo) (int, []models.ScoreComponent) {
+	components := s.strategy.score(s.weights, r)
+	total := 0.0
+	for _, c := range components {
+		total += c.Points
+	}
+	rounded := int(math.Round(total))
+	return max(0, min(rounded, 100)), components
+}
+
+// version names a strategy revision, as in "weighted.v1". Non-default
+// weights add a short hash of all weights, as in "weighted.v1+3fa9c1d2", so
+// each weighting gets its own version.
+func version(name string, revision int, defaults, weights Weights) string {
+	v := fmt.Sprintf("%s.v%d", name, revision)
+	custom := false
+	for k, w := range weights {
+		if defaults[k] != w {
+			custom = true
+		}
+	}
+	if !custom {
+		return v
+	}
+
+	keys := make([]string, 0, len(weights))
+	for k := range weights {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%g\n", k, weights[k])
+	}
+	return v + "+" + hex.EncodeToString(h.Sum(nil))[:8]
+}
@@ -0,0 +1,84 @@
+package scoring
+
+import (
+	"math"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// weightedDefaults are the weights of the idea score. Stars, forks and star
+// velocity count logarithmically; description_length is awarded in full at
+// 120 characters and lifespan at a year. recent_death is awarded in full for
+// fossils dead up to two years, fading to nothing at ten. The license weights
+// are what each license class adds: code nobody may reuse is worth much less
+// to a reviver than its stars suggest.
+var weightedDefaults = Weights{
+	"stars":              6,
+	"forks":              4,
+	"star_velocity":      3,
+	"lifespan":           3,
+	"recent_death":       5,
+	"description":        10,
+	"description_length": 10,
+	"topics":             5,
+	"readme":             5,
+	"license_permissive": 8,
+	"license_copyleft":   5,
+	"license_unknown":    2,
+	"license_none":       -10,
+}
+
+// weighted scores popularity, how fast it came and how long the project
+// lasted, and how well a repo explains itself. Star velocity separates 200
+// stars in three months from 200 over ten years. Without a description, the
+// README excerpt carries the length signal. Revision 2 added the time-aware
+// components.
+func weighted(w Weights, r models.Repo) []models.ScoreComponent {
+	text := r.Description
+	if text == "" {
+		text = r.ReadmeExcerpt
+	}
+	descLen := min(len(text), 120)
+	license := "license_" + r.LicenseClass
+
+	return []models.ScoreComponent{
+		logComponent("stars", float64(r.Stargazers), w),
+		logComponent("forks", float64(r.Forks), w),
+		logComponent("star_velocity", r.StarsPerMonth, w),
+		{Name: "lifespan", Value: r.LifespanMonths, Points: min(r.LifespanMonths, 12) / 12 * w["lifespan"]},
+		{Name: "recent_death", Value: r.MonthsSinceDeath, Points: max(0, min((120-r.MonthsSinceDeath)/96, 1)) * w["recent_death"]},
+		flagComponent("description", r.Description != "", w),
+		{Name: "description_length", Value: float64(descLen), Points: float64(descLen) / 120 * w["description_length"]},
+		flagComponent("topics", len(r.Topics) > 0, w),
+		flagComponent("readme", r.ReadmeExcerpt != "", w),
+		flagComponent(license, true, w),
+	}
+}
+
+var popularityDefaults = Weights{
+	"stars": 10,
+	"forks": 5,
+}
+
+// popularity scores stars and forks alone, as a baseline to compare other
+// strategies against.
+func popularity(w Weights, r models.Repo) []models.ScoreComponent {
+	return []models.ScoreComponent{
+		logComponent("stars", float64(r.Stargazers), w),
+		logComponent("forks", float64(r.Forks), w),
+	}
+}
+
+// logComponent awards the named weight per doubling of n.
+func logComponent(name string, n float64, w Weights) models.ScoreComponent {
+	return models.ScoreComponent{Name: name, Value: n, Points: math.Log2(n+1) * w[name]}
+}
+
+// flagComponent awards the named weight when b holds.
+func flagComponent(name string, b bool, w Weights) models.ScoreComponent {
+	c := models.ScoreComponent{Name: name}
+	if b {
+		c.Value, c.Points = 1, w[name]
+	}
+	return c
+}
@@ -0,0 +1,117 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// Bitbucket searches public Bitbucket Cloud repositories. Bitbucket has no
+// stars and no numeric repo IDs: MinStars is not applied, and IDs are
+// derived from the repo UUID.
+type Bitbucket struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewBitbucket(baseURL string) *Bitbucket {
+	return &Bitbucket{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: newHTTPClient(),
+	}
+}
+
+func (b *Bitbucket) Name() string {
+	return models.SourceBitbucket
+}
+
+func (b *Bitbucket) FetchStaleRepos(ctx context.Context, queries []models.DiscoveryQuery) ([]models.QueryResult, error) {
+	return sample(ctx, b.Name(), queries, b.search)
+}
+
+// bitbucketSort maps discovery sort options onto sortable repository fields.
+var bitbucketSort = map[string]string{
+	"updated": "-updated_on",
+}
+
+type bitbucketPage struct {
+	Values []bitbucketRepo `json:"values"`
+}
+
+type bitbucketRepo struct {
+	UUID        string    `json:"uuid"`
+	Name        string    `json:"name"`
+	FullName    string    `json:"full_name"`
+	Description string    `json:"description"`
+	Language    string    `json:"language"`
+	Size        int       `json:"size"` // bytes
+	CreatedOn   time.Time `json:"created_on"`
+	UpdatedOn   time.Time `json:"updated_on"`
+	Parent      *struct{} `json:"parent"` // set on forks
+	Links       struct {
+		HTML struct{ Href string } `json:"html"`
+	} `json:"links"`
+	Owner struct {
+		Links struct {
+			Avatar struct{ Href string } `json:"avatar"`
+		} `json:"links"`
+	} `json:"owner"`
+}
+
+func (b *Bitbucket) search(ctx context.Context, q models.DiscoveryQuery, sortBy string, page int) ([]models.Repo, int, error) {
+	cutoff := time.Now().AddDate(0, 0, -q.StaleAfterDays).UTC().Format("2006-01-02T15:04:05")
+	filter := "is_private = false AND updated_on < " + cutoff
+	if terms := strings.ReplaceAll(searchTerms(q.Query), `"`, ""); terms != "" {
+		filter += fmt.Sprintf(` AND (name ~ "%s" OR description ~ "%s")`, terms, terms)
+	}
+
+	params := url.Values{}
+	params.Set("q", filter)
+	if s, ok := bitbucketSort[sortBy]; ok {
+		params.Set("sort", s)
+	}
+	params.Set("pagelen", fmt.Sprint(perPage))
+	params.Set("page", fmt.Sprint(page))
+
+	var result bitbucketPage
+	if err := getJSON(ctx, b.httpClient, b.baseURL+"/repositories?"+params.Encode(), nil, &result); err != nil {
+		return nil, 0, err
+	}
+
+	var repos []models.Repo
+	for _, r := range result.Values {
+		if r.Parent != nil {
+			continue
+		}
+		repo := r.toModel()
+		if staleEnough(repo, q) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, len(result.Values), nil
+}
+
+func (r bitbucketRepo) toModel() models.Repo {
+	owner, _, _ := strings.Cut(r.FullName, "/")
+	return models.Repo{
+		Source:      models.SourceBitbucket,
+		ID:          stableID(r.UUID),
+		Name:        r.Name,
+		FullName:    r.FullName,
+		OwnerLogin:  owner,
+		OwnerAvatar: r.Owner.Links.Avatar.Href,
+		HTMLURL:     r.Links.HTML.Href,
+		Description: r.Description,
+		Language:    r.Language,
+		Topics:      []string{},
+		PushedAt:    r.UpdatedOn,
+		CreatedAt:   r.CreatedOn,
+		DiskUsageKB: r.Size / 1024,
+		License:     nil, // Bitbucket doesn't detect licenses
+	}
+}
@@ -0,0 +1,151 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// Gitea searches a Gitea or Forgejo instance such as Codeberg. Both share
+// the same v1 API.
+type Gitea struct {
+	name       string
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func NewGitea(baseURL, token string) *Gitea {
+	baseURL = strings.TrimRight(baseURL, "/")
+	host := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return &Gitea{
+		name:       models.GiteaSource(host),
+		baseURL:    baseURL,
+		token:      token,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name includes the instance's host, e.g. gitea:codeberg.org.
+func (g *Gitea) Name() string {
+	return g.name
+}
+
+func (g *Gitea) FetchStaleRepos(ctx context.Context, queries []models.DiscoveryQuery) ([]models.QueryResult, error) {
+	return sample(ctx, g.Name(), queries, g.search)
+}
+
+// giteaSort maps discovery sort options onto the search API's sort values;
+// best-match leaves the server's relevance order.
+var giteaSort = map[string]string{
+	"stars":   "stars",
+	"forks":   "forks",
+	"updated": "updated",
+}
+
+type giteaSearchResponse struct {
+	OK   bool        `json:"ok"`
+	Data []giteaRepo `json:"data"`
+}
+
+type giteaRepo struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    struct {
+		Login     string `json:"login"`
+		AvatarURL string `json:"avatar_url"`
+	} `json:"owner"`
+	HTMLURL         string    `json:"html_url"`
+	Description     string    `json:"description"`
+	Language        string    `json:"language"`
+	Topics          []string  `json:"topics"`
+	StarsCount      int       `json:"stars_count"`
+	ForksCount      int       `json:"forks_count"`
+	OpenIssuesCount int       `json:"open_issues_count"`
+	Size            int       `json:"size"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	CreatedAt       time.Time `json:"created_at"`
+	Archived        bool      `json:"archived"`
+	Fork            bool      `json:"fork"`
+	Mirror          bool      `json:"mirror"`
+	Licenses        *[]string `json:"licenses"` // SPDX ids; missing before Gitea 1.22
+}
+
+func (g *Gitea) search(ctx context.Context, q models.DiscoveryQuery, sortBy string, page int) ([]models.Repo, int, error) {
+	params := url.Values{}
+	params.Set("q", searchTerms(q.Query))
+	params.Set("includeDesc", "true")
+	if s, ok := giteaSort[sortBy]; ok {
+		params.Set("sort", s)
+		params.Set("order", "desc")
+	}
+	params.Set("limit", fmt.Sprint(perPage))
+	params.Set("page", fmt.Sprint(page))
+
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("Authorization", "token "+g.token)
+	}
+
+	var result giteaSearchResponse
+	if err := getJSON(ctx, g.httpClient, g.baseURL+"/api/v1/repos/search?"+params.Encode(), header, &result); err != nil {
+		return nil, 0, err
+	}
+
+	var repos []models.Repo
+	for _, r := range result.Data {
+		// Forks and mirrors are copies of projects that live elsewhere.
+		if r.Fork || r.Mirror {
+			continue
+		}
+		repo := r.toModel(g.name)
+		if repo.Stargazers >= q.MinStars && staleEnough(repo, q) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, len(result.Data), nil
+}
+
+func (r giteaRepo) toModel(source string) models.Repo {
+	topics := r.Topics
+	if topics == nil {
+		topics = []string{}
+	}
+	var license *string // older Gitea versions don't report licenses
+	if r.Licenses != nil {
+		spdxID := ""
+		if len(*r.Licenses) > 0 {
+			spdxID = (*r.Licenses)[0]
+		}
+		license = &spdxID
+	}
+	return models.Repo{
+		Source:      source,
+		ID:          r.ID,
+		Name:        r.Name,
+		FullName:    r.FullName,
+		OwnerLogin:  r.Owner.Login,
+		OwnerAvatar: r.Owner.AvatarURL,
+		HTMLURL:     r.HTMLURL,
+		Description: r.Description,
+		Language:    r.Language,
+		Topics:      topics,
+		Stargazers:  r.StarsCount,
+		Forks:       r.ForksCount,
+		PushedAt:    r.UpdatedAt,
+		CreatedAt:   r.CreatedAt,
+		Archived:    r.Archived,
+		OpenIssues:  r.OpenIssuesCount,
+		DiskUsageKB: r.Size,
+		License:     license,
+	}
+}
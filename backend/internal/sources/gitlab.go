@@ -0,0 +1,135 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// GitLab searches public projects on gitlab.com or a self-managed instance.
+type GitLab struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func NewGitLab(baseURL, token string) *GitLab {
+	return &GitLab{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: newHTTPClient(),
+	}
+}
+
+func (g *GitLab) Name() string {
+	return models.SourceGitLab
+}
+
+func (g *GitLab) FetchStaleRepos(ctx context.Context, queries []models.DiscoveryQuery) ([]models.QueryResult, error) {
+	return sample(ctx, g.Name(), queries, g.search)
+}
+
+// gitlabOrderBy maps discovery sort options onto the projects API's order_by.
+var gitlabOrderBy = map[string]string{
+	"stars":   "star_count",
+	"updated": "last_activity_at",
+}
+
+type gitlabProject struct {
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	PathWithNamespace string    `json:"path_with_namespace"`
+	WebURL            string    `json:"web_url"`
+	Description       *string   `json:"description"`
+	AvatarURL         *string   `json:"avatar_url"`
+	Topics            []string  `json:"topics"`
+	TagList           []string  `json:"tag_list"` // topics before GitLab 14.5
+	StarCount         int       `json:"star_count"`
+	ForksCount        int       `json:"forks_count"`
+	OpenIssuesCount   int       `json:"open_issues_count"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	Archived          bool      `json:"archived"`
+	Namespace         struct {
+		Path      string  `json:"path"`
+		AvatarURL *string `json:"avatar_url"`
+	} `json:"namespace"`
+}
+
+func (g *GitLab) search(ctx context.Context, q models.DiscoveryQuery, sortBy string, page int) ([]models.Repo, int, error) {
+	params := url.Values{}
+	params.Set("search", searchTerms(q.Query))
+	params.Set("visibility", "public")
+	params.Set("last_activity_before", time.Now().AddDate(0, 0, -q.StaleAfterDays).UTC().Format(time.RFC3339))
+	if orderBy, ok := gitlabOrderBy[sortBy]; ok {
+		params.Set("order_by", orderBy)
+		params.Set("sort", "desc")
+	}
+	params.Set("per_page", fmt.Sprint(perPage))
+	params.Set("page", fmt.Sprint(page))
+
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	var projects []gitlabProject
+	if err := getJSON(ctx, g.httpClient, g.baseURL+"/api/v4/projects?"+params.Encode(), header, &projects); err != nil {
+		return nil, 0, err
+	}
+
+	var repos []models.Repo
+	for _, p := range projects {
+		repo := p.toModel()
+		if repo.Stargazers >= q.MinStars && staleEnough(repo, q) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, len(projects), nil
+}
+
+func (p gitlabProject) toModel() models.Repo {
+	desc := ""
+	if p.Description != nil {
+		desc = *p.Description
+	}
+	avatar := ""
+	switch {
+	case p.Namespace.AvatarURL != nil:
+		avatar = *p.Namespace.AvatarURL
+	case p.AvatarURL != nil:
+		avatar = *p.AvatarURL
+	}
+
+	topics := p.Topics
+	if len(topics) == 0 {
+		topics = p.TagList
+	}
+	if topics == nil {
+		topics = []string{}
+	}
+
+	return models.Repo{
+		Source:      models.SourceGitLab,
+		ID:          p.ID,
+		Name:        p.Name,
+		FullName:    p.PathWithNamespace,
+		OwnerLogin:  p.Namespace.Path,
+		OwnerAvatar: avatar,
+		HTMLURL:     p.WebURL,
+		Description: desc,
+		Topics:      topics,
+		Stargazers:  p.StarCount,
+		Forks:       p.ForksCount,
+		PushedAt:    p.LastActivityAt,
+		CreatedAt:   p.CreatedAt,
+		Archived:    p.Archived,
+		OpenIssues:  p.OpenIssuesCount,
+		License:     nil, // the project list doesn't include licenses
+	}
+}
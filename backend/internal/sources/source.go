@@ -0,0 +1,179 @@
+// Package sources ingests fossils from code hosts other than GitHub. Every
+// adapter maps its host's JSON into models.Repo; github.Client satisfies the
+// same Source interface.
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmetburakdinc/codefossils/internal/models"
+)
+
+// Source is a code host that discovery queries can be run against.
+type Source interface {
+	// Name is stored in repos.source; together with the repo ID it
+	// identifies a repo.
+	Name() string
+	// FetchStaleRepos runs a sample of the queries and returns the results
+	// per query. When ctx is done it returns what it has so far together
+	// with ctx's error.
+	FetchStaleRepos(ctx context.Context, queries []models.DiscoveryQuery) ([]models.QueryResult, error)
+}
+
+const (
+	// queriesPerRun is how many discovery queries a sampled refresh runs.
+	queriesPerRun = 3
+	// samplePages is how many leading result pages are sampled from. These
+	// hosts are much smaller than GitHub, so results run out sooner.
+	samplePages = 3
+	perPage     = 30
+)
+
+// searchFunc fetches one page of a host's results for a query. It returns
+// repos already filtered down to fossils, plus the number it fetched.
+type searchFunc func(ctx context.Context, q models.DiscoveryQuery, sortBy string, page int) (repos []models.Repo, fetched int, err error)
+
+// sample mirrors github.Client.FetchStaleRepos: a few random queries, each on
+// a random page with one of its sort options, searched concurrently and
+// deduplicated across queries.
+func sample(ctx context.Context, source string, queries []models.DiscoveryQuery, search searchFunc) ([]models.QueryResult, error) {
+	shuffled := make([]models.DiscoveryQuery, len(queries))
+	copy(shuffled, queries)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	if len(shuffled) > queriesPerRun {
+		shuffled = shuffled[:queriesPerRun]
+	}
+
+	type pick struct {
+		query   models.DiscoveryQuery
+		sortBy  string
+		page    int
+		repos   []models.Repo
+		fetched int
+		err     error
+	}
+	picks := make([]pick, len(shuffled))
+	for i, q := range shuffled {
+		sortBy := "best-match"
+		if len(q.SortOptions) > 0 {
+			sortBy = q.SortOptions[rand.Intn(len(q.SortOptions))]
+		}
+		picks[i] = pick{query: q, sortBy: sortBy, page: rand.Intn(samplePages) + 1}
+	}
+
+	// At most queriesPerRun searches, so one goroutine each.
+	var wg sync.WaitGroup
+	for i := range picks {
+		wg.Add(1)
+		go func(p *pick) {
+			defer wg.Done()
+			p.repos, p.fetched, p.err = search(ctx, p.query, p.sortBy, p.page)
+		}(&picks[i])
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool)
+	var results []models.QueryResult
+	total := 0
+
+	for _, p := range picks {
+		if p.err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error fetching query %q from %s: %v", p.query.Query, source, p.err)
+			}
+			continue
+		}
+
+		result := models.QueryResult{Query: p.query}
+		for _, repo := range p.repos {
+			if seen[repo.ID] {
+				result.Duplicates++
+				continue
+			}
+			seen[repo.ID] = true
+			result.Repos = append(result.Repos, repo)
+		}
+		results = append(results, result)
+		total += len(result.Repos)
+
+		log.Printf("Fetched %d repos from %s for query %q (sort=%s, page=%d), %d stale enough",
+			p.fetched, source, p.query.Query, p.sortBy, p.page, len(p.repos))
+	}
+
+	log.Printf("Total unique repos fetched from %s: %d", source, total)
+	return results, ctx.Err()
+}
+
+// searchTerms reduces a discovery query, written in GitHub search syntax, to
+// the plain keywords other hosts understand: qualifiers (key:value) and
+// negated terms are dropped.
+func searchTerms(query string) string {
+	var terms []string
+	fields := strings.Fields(query)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "NOT":
+			i++ // skip the negated term too
+		case strings.HasPrefix(f, "-"), strings.Contains(f, ":"), f == "AND", f == "OR":
+		default:
+			terms = append(terms, f)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// staleEnough reports whether a repo's last activity is older than the
+// query's cutoff. The hosts here can't filter on it server-side.
+func staleEnough(repo models.Repo, q models.DiscoveryQuery) bool {
+	return repo.PushedAt.Before(time.Now().AddDate(0, 0, -q.StaleAfterDays))
+}
+
+// getJSON fetches url and decodes a 200 response into v.
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	for k, vals := range header {
+		req.Header[k] = vals
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", req.URL.Host, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// stableID derives a positive 63-bit ID from a host's string identifier, for
+// hosts that have no numeric repo IDs.
+func stableID(s string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return int64(h.Sum64() & (1<<63 - 1))
+}
@@ -0,0 +1,235 @@
+package stack
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// The parsers below are deliberately lenient: manifests in abandoned repos
+// are often half-edited, and a partial dependency list is still useful. None
+// of them fails; unreadable input just yields fewer dependencies.
+
+func parsePackageJSON(content string) []dependency {
+	var pkg struct {
+		Dependencies     map[string]interface{} `json:"dependencies"`
+		DevDependencies  map[string]interface{} `json:"devDependencies"`
+		PeerDependencies map[string]interface{} `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil
+	}
+	var deps []dependency
+	for _, m := range []map[string]interface{}{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies} {
+		for name := range m {
+			deps = append(deps, dependency{npm, name})
+		}
+	}
+	return deps
+}
+
+// parseGoMod reads require directives, both single-line and blocks.
+func parseGoMod(content string) []dependency {
+	var deps []dependency
+	inBlock := false
+	for _, line := range lines(content, "//") {
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			deps = appendField(deps, gomod, line)
+		case line == "require (":
+			inBlock = true
+		case strings.HasPrefix(line, "require "):
+			deps = appendField(deps, gomod, strings.TrimPrefix(line, "require "))
+		}
+	}
+	return deps
+}
+
+// requirementNameRe matches the distribution name at the start of a PEP 508
+// requirement such as "Django>=3.2; python_version > '3'".
+var requirementNameRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+func parseRequirements(content string) []dependency {
+	var deps []dependency
+	for _, line := range lines(content, "#") {
+		if strings.HasPrefix(line, "-") {
+			continue // -r other.txt, -e ., --index-url ...
+		}
+		if m := requirementNameRe.FindStringSubmatch(line); m != nil {
+			deps = append(deps, dependency{pypi, m[1]})
+		}
+	}
+	return deps
+}
+
+// parsePyproject reads PEP 621 dependency arrays and Poetry dependency
+// tables. It understands just enough TOML for those two shapes.
+func parsePyproject(content string) []dependency {
+	var deps []dependency
+	section := ""
+	inArray := false
+	for _, line := range lines(content, "#") {
+		if inArray {
+			deps = appendQuoted(deps, pypi, line)
+			if strings.Contains(line, "]") {
+				inArray = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case section == "project" && key == "dependencies",
+			section == "project.optional-dependencies",
+			section == "dependency-groups",
+			section == "build-system" && key == "requires":
+			if strings.HasPrefix(value, "[") {
+				deps = appendQuoted(deps, pypi, value)
+				inArray = !strings.Contains(value, "]")
+			}
+		case strings.HasPrefix(section, "tool.poetry.") && strings.HasSuffix(section, "dependencies"):
+			if key != "python" {
+				deps = append(deps, dependency{pypi, strings.Trim(key, `"'`)})
+			}
+		}
+	}
+	return deps
+}
+
+// parseCargo reads the keys of Cargo's dependency tables, including the
+// [dependencies.name] form.
+func parseCargo(content string) []dependency {
+	var deps []dependency
+	section := ""
+	for _, line := range lines(content, "#") {
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			if name, ok := cargoDependencyTable(section); ok && name != "" {
+				deps = append(deps, dependency{cargo, name})
+			}
+			continue
+		}
+		if name, ok := cargoDependencyTable(section); ok && name == "" {
+			if key, _, found := strings.Cut(line, "="); found {
+				deps = append(deps, dependency{cargo, strings.Trim(strings.TrimSpace(key), `"'`)})
+			}
+		}
+	}
+	return deps
+}
+
+// cargoDependencyTable reports whether a table header is a dependency table
+// and, for [dependencies.name] style tables, which crate it declares.
+func cargoDependencyTable(section string) (name string, ok bool) {
+	for _, kind := range []string{"dependencies", "dev-dependencies", "build-dependencies"} {
+		// Also matches workspace.dependencies and target.'cfg(..)'.dependencies.
+		if section == kind || strings.HasSuffix(section, "."+kind) {
+			return "", true
+		}
+		if i := strings.Index(section, kind+"."); i == 0 || (i > 0 && section[i-1] == '.') {
+			return section[i+len(kind)+1:], true
+		}
+	}
+	return "", false
+}
+
+var gemRe = regexp.MustCompile(`^gem\s*\(?\s*["']([^"']+)["']`)
+
+func parseGemfile(content string) []dependency {
+	var deps []dependency
+	for _, line := range lines(content, "#") {
+		if m := gemRe.FindStringSubmatch(line); m != nil {
+			deps = append(deps, dependency{gem, m[1]})
+		}
+	}
+	return deps
+}
+
+var (
+	pomDependencyRe = regexp.MustCompile(`(?s)<(dependency|parent|plugin)>(.*?)</(dependency|parent|plugin)>`)
+	pomGroupRe      = regexp.MustCompile(`<groupId>\s*([^<\s]+)\s*</groupId>`)
+	pomArtifactRe   = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+)
+
+func parsePom(content string) []dependency {
+	var deps []dependency
+	for _, m := range pomDependencyRe.FindAllStringSubmatch(content, -1) {
+		group := pomGroupRe.FindStringSubmatch(m[2])
+		artifact := pomArtifactRe.FindStringSubmatch(m[2])
+		if group == nil || artifact == nil {
+			continue
+		}
+		deps = append(deps, dependency{maven, group[1] + ":" + artifact[1]})
+	}
+	return deps
+}
+
+var (
+	// gradleCoordinateRe matches "group:artifact:version" string notation
+	// in both Groovy and Kotlin DSL.
+	gradleCoordinateRe = regexp.MustCompile(`["']([A-Za-z0-9_.-]+):([A-Za-z0-9_.-]+)(?::[^"']*)?["']`)
+	// gradlePluginRe matches id 'x', id "x" and id("x") in plugins blocks.
+	gradlePluginRe = regexp.MustCompile(`\bid\s*\(?\s*["']([A-Za-z0-9_.-]+)["']`)
+	// gradleApplyRe matches the older apply plugin: 'x' form.
+	gradleApplyRe = regexp.MustCompile(`apply\s+plugin\s*:\s*["']([A-Za-z0-9_.-]+)["']`)
+)
+
+func parseGradle(content string) []dependency {
+	var deps []dependency
+	for _, m := range gradleCoordinateRe.FindAllStringSubmatch(content, -1) {
+		deps = append(deps, dependency{maven, m[1] + ":" + m[2]})
+	}
+	for _, re := range []*regexp.Regexp{gradlePluginRe, gradleApplyRe} {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			deps = append(deps, dependency{plugin, m[1]})
+		}
+	}
+	return deps
+}
+
+// lines splits content into trimmed, non-empty lines with comments starting
+// at comment removed.
+func lines(content, comment string) []string {
+	var out []string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, comment); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// appendField appends the first whitespace-separated field of s.
+func appendField(deps []dependency, ecosystem, s string) []dependency {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		deps = append(deps, dependency{ecosystem, fields[0]})
+	}
+	return deps
+}
+
+var quotedRe = regexp.MustCompile(`["']([^"']+)["']`)
+
+// appendQuoted appends the requirement names found in the quoted strings of
+// a TOML array line.
+func appendQuoted(deps []dependency, ecosystem, s string) []dependency {
+	for _, m := range quotedRe.FindAllStringSubmatch(s, -1) {
+		if name := requirementNameRe.FindString(strings.TrimSpace(m[1])); name != "" {
+			deps = append(deps, dependency{ecosystem, name})
+		}
+	}
+	return deps
+}
@@ -0,0 +1,204 @@
+// Package stack detects the frameworks and libraries a repo is built with
+// from its dependency manifests. Only dependencies that say something about
+// what a project is (Django, React Native, PyTorch, ...) are reported;
+// utility packages are ignored.
+package stack
+
+import (
+	"sort"
+	"strings"
+)
+
+// Ecosystems, one per manifest format family.
+const (
+	npm    = "npm"
+	gomod  = "go"
+	pypi   = "pypi"
+	cargo  = "cargo"
+	gem    = "gem"
+	maven  = "maven" // pom.xml and build.gradle, as group:artifact
+	plugin = "gradle-plugin"
+)
+
+// manifests maps the file names we read to their parser.
+var manifests = map[string]func(content string) []dependency{
+	"package.json":     parsePackageJSON,
+	"go.mod":           parseGoMod,
+	"requirements.txt": parseRequirements,
+	"pyproject.toml":   parsePyproject,
+	"Cargo.toml":       parseCargo,
+	"Gemfile":          parseGemfile,
+	"pom.xml":          parsePom,
+	"build.gradle":     parseGradle,
+	"build.gradle.kts": parseGradle,
+}
+
+// IsManifest reports whether name is a manifest file Detect understands.
+func IsManifest(name string) bool {
+	_, ok := manifests[name]
+	return ok
+}
+
+// dependency is one declared dependency in its ecosystem's naming.
+type dependency struct {
+	ecosystem string
+	name      string
+}
+
+// Detect parses the given manifests, keyed by file name, and returns the
+// known frameworks and libraries among their dependencies, sorted and
+// deduplicated. Unknown file names are ignored.
+func Detect(files map[string]string) []string {
+	found := make(map[string]bool)
+	for name, content := range files {
+		parse, ok := manifests[name]
+		if !ok {
+			continue
+		}
+		for _, dep := range parse(content) {
+			if s := lookup(dep); s != "" {
+				found[s] = true
+			}
+		}
+	}
+
+	stack := make([]string, 0, len(found))
+	for s := range found {
+		stack = append(stack, s)
+	}
+	sort.Strings(stack)
+	return stack
+}
+
+// lookup maps a dependency to its stack name. Go modules and Maven
+// coordinates also match by prefix, so github.com/gin-gonic/gin/v2 and
+// org.springframework.boot:spring-boot-starter-web are recognised.
+func lookup(dep dependency) string {
+	names := known[dep.ecosystem]
+	name := dep.name
+	if dep.ecosystem == pypi || dep.ecosystem == npm {
+		name = strings.ToLower(name)
+	}
+	if dep.ecosystem == pypi {
+		name = strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	}
+	if s, ok := names[name]; ok {
+		return s
+	}
+	if dep.ecosystem != gomod && dep.ecosystem != maven {
+		return ""
+	}
+	// The longest prefix wins: org.springframework.boot over org.springframework.
+	best, bestLen := "", 0
+	for prefix, s := range names {
+		if len(prefix) <= bestLen {
+			continue
+		}
+		if strings.HasPrefix(name, prefix+"/") || strings.HasPrefix(name, prefix+":") ||
+			strings.HasPrefix(name, prefix+".") {
+			best, bestLen = s, len(prefix)
+		}
+	}
+	return best
+}
+
+// known lists the dependencies worth reporting, per ecosystem. Several
+// packages can map to one stack name.
+var known = map[string]map[string]string{
+	npm: {
+		"react": "react", "react-dom": "react", "next": "next.js", "vue": "vue", "nuxt": "nuxt",
+		"@angular/core": "angular", "svelte": "svelte", "@sveltejs/kit": "sveltekit",
+		"solid-js": "solid", "preact": "preact", "gatsby": "gatsby", "astro": "astro",
+		"express": "express", "koa": "koa", "fastify": "fastify", "@nestjs/core": "nestjs",
+		"hapi": "hapi", "@hapi/hapi": "hapi",
+		"react-native": "react-native", "expo": "expo", "@ionic/core": "ionic",
+		"@ionic/angular": "ionic", "@ionic/react": "ionic", "@capacitor/core": "capacitor",
+		"nativescript": "nativescript", "@nativescript/core": "nativescript",
+		"electron": "electron", "@tauri-apps/api": "tauri",
+		"three": "three.js", "phaser": "phaser", "pixi.js": "pixi.js", "babylonjs": "babylon.js",
+		"@babylonjs/core": "babylon.js", "kaboom": "kaboom",
+		"@tensorflow/tfjs": "tensorflow", "openai": "openai", "langchain": "langchain",
+		"@langchain/core": "langchain", "brain.js": "brain.js",
+		"d3": "d3", "chart.js": "chart.js", "echarts": "echarts", "puppeteer": "puppeteer",
+		"playwright": "playwright", "cheerio": "cheerio",
+		"commander": "commander", "yargs": "yargs", "inquirer": "inquirer", "oclif": "oclif",
+		"@oclif/core": "oclif", "vscode": "vscode-extension", "@types/vscode": "vscode-extension",
+		"discord.js": "discord.js", "telegraf": "telegraf", "web3": "web3", "ethers": "ethers",
+		"mongoose": "mongoose", "prisma": "prisma", "@prisma/client": "prisma", "socket.io": "socket.io",
+	},
+	gomod: {
+		"github.com/gin-gonic/gin":                        "gin",
+		"github.com/labstack/echo":                        "echo",
+		"github.com/gofiber/fiber":                        "fiber",
+		"github.com/go-chi/chi":                           "chi",
+		"github.com/gorilla/mux":                          "gorilla-mux",
+		"github.com/beego/beego":                          "beego",
+		"github.com/spf13/cobra":                          "cobra",
+		"github.com/urfave/cli":                           "urfave-cli",
+		"github.com/charmbracelet/bubbletea":              "bubbletea",
+		"github.com/rivo/tview":                           "tview",
+		"github.com/hajimehoshi/ebiten":                   "ebiten",
+		"fyne.io/fyne":                                    "fyne",
+		"github.com/wailsapp/wails":                       "wails",
+		"gorm.io/gorm":                                    "gorm",
+		"github.com/gocolly/colly":                        "colly",
+		"github.com/ethereum/go-ethereum":                 "go-ethereum",
+		"github.com/bwmarrin/discordgo":                   "discordgo",
+		"github.com/go-telegram-bot-api/telegram-bot-api": "telegram-bot-api",
+		"github.com/sashabaranov/go-openai":               "openai",
+		"github.com/tmc/langchaingo":                      "langchain",
+		"k8s.io/client-go":                                "kubernetes",
+		"github.com/hashicorp/terraform-plugin-sdk":       "terraform-provider",
+	},
+	pypi: {
+		"django": "django", "flask": "flask", "fastapi": "fastapi", "tornado": "tornado",
+		"pyramid": "pyramid", "starlette": "starlette", "aiohttp": "aiohttp", "streamlit": "streamlit",
+		"dash": "dash", "gradio": "gradio",
+		"torch": "pytorch", "pytorch": "pytorch", "tensorflow": "tensorflow", "keras": "keras",
+		"jax": "jax", "transformers": "transformers", "scikit-learn": "scikit-learn",
+		"sklearn": "scikit-learn", "xgboost": "xgboost", "lightgbm": "lightgbm", "openai": "openai",
+		"langchain": "langchain", "spacy": "spacy", "nltk": "nltk", "opencv-python": "opencv",
+		"diffusers": "diffusers", "pandas": "pandas", "numpy": "numpy", "polars": "polars", "pyspark": "spark",
+		"apache-airflow": "airflow", "dbt-core": "dbt", "matplotlib": "matplotlib", "plotly": "plotly",
+		"seaborn": "seaborn", "sqlalchemy": "sqlalchemy", "scrapy": "scrapy",
+		"beautifulsoup4": "beautifulsoup", "selenium": "selenium", "jupyter": "jupyter",
+		"click": "click", "typer": "typer", "rich": "rich", "textual": "textual",
+		"pygame": "pygame", "arcade": "arcade", "pyglet": "pyglet", "kivy": "kivy",
+		"pyqt5": "qt", "pyqt6": "qt", "pyside6": "qt", "discord-py": "discord.py",
+		"python-telegram-bot": "python-telegram-bot", "web3": "web3",
+	},
+	cargo: {
+		"actix-web": "actix-web", "axum": "axum", "rocket": "rocket", "warp": "warp",
+		"yew": "yew", "leptos": "leptos", "dioxus": "dioxus", "tauri": "tauri",
+		"tokio": "tokio", "clap": "clap", "ratatui": "ratatui", "tui": "ratatui",
+		"bevy": "bevy", "macroquad": "macroquad", "ggez": "ggez", "amethyst": "amethyst",
+		"wgpu": "wgpu", "egui": "egui", "iced": "iced", "diesel": "diesel", "sqlx": "sqlx",
+		"serenity": "serenity", "candle-core": "candle", "tch": "pytorch", "burn": "burn",
+		"polars": "polars", "embedded-hal": "embedded-hal", "cortex-m": "cortex-m",
+		"solana-program": "solana", "ethers": "ethers",
+	},
+	gem: {
+		"rails": "rails", "sinatra": "sinatra", "hanami": "hanami", "jekyll": "jekyll",
+		"middleman": "middleman", "thor": "thor", "discordrb": "discordrb", "gosu": "gosu",
+		"nokogiri": "nokogiri", "sidekiq": "sidekiq", "rspec": "rspec",
+	},
+	maven: {
+		"org.springframework.boot": "spring-boot", "org.springframework": "spring",
+		"io.quarkus": "quarkus", "io.micronaut": "micronaut", "io.vertx": "vert.x",
+		"io.dropwizard": "dropwizard", "io.ktor": "ktor",
+		"androidx": "android", "com.android.support": "android",
+		"com.badlogicgames.gdx": "libgdx", "org.lwjgl": "lwjgl",
+		"org.apache.spark": "spark", "org.apache.kafka": "kafka", "org.apache.flink": "flink",
+		"org.deeplearning4j": "deeplearning4j", "org.tensorflow": "tensorflow",
+		"org.hibernate": "hibernate", "org.jsoup": "jsoup", "info.picocli": "picocli",
+		"net.dv8tion": "jda", "org.bukkit": "minecraft-plugin", "org.spigotmc": "minecraft-plugin",
+		"io.papermc.paper": "minecraft-plugin", "net.minecraftforge": "minecraft-mod",
+		"net.fabricmc": "minecraft-mod", "org.openjfx": "javafx",
+	},
+	plugin: {
+		"com.android.application": "android", "com.android.library": "android",
+		"org.springframework.boot": "spring-boot", "io.quarkus": "quarkus",
+		"io.ktor.plugin": "ktor", "org.openjfx.javafxplugin": "javafx",
+		"net.minecraftforge.gradle": "minecraft-mod", "fabric-loom": "minecraft-mod",
+	},
+}
@@ -160,7 +160,7 @@ export default function App() {
             }}>
               {repos.map((repo, i) => (
                 <Tombstone
-                  key={repo.id}
+                  key={`${repo.source}-${repo.id}`}
                   repo={repo}
                   index={i}
                   animate={appendedFrom === null || i < appendedFrom}
@@ -1,10 +1,11 @@
 const BASE = '';
 
-export async function fetchRepos({ category, sort, search, page, perPage } = {}) {
+export async function fetchRepos({ category, sort, search, page, perPage, collapseClusters } = {}) {
   const params = new URLSearchParams();
   if (category && category !== 'all') params.set('category', category);
   if (sort) params.set('sort', sort);
   if (search) params.set('search', search);
+  if (collapseClusters) params.set('collapse_clusters', 'true');
   if (page) params.set('page', String(page));
   if (perPage) params.set('per_page', String(perPage));
 
@@ -19,8 +20,34 @@ export async function refreshRepos() {
   return res.json();
 }
 
+export async function fetchRepo(source, id) {
+  const params = new URLSearchParams({ source: source || 'github' });
+  const res = await fetch(`${BASE}/api/repos/${id}?${params}`);
+  if (!res.ok) throw new Error(`Failed to fetch repo: ${res.status}`);
+  return res.json();
+}
+
+export async function fetchReadme(source, id) {
+  const params = new URLSearchParams({ source: source || 'github' });
+  const res = await fetch(`${BASE}/api/repos/${id}/readme?${params}`);
+  if (!res.ok) throw new Error(`Failed to fetch README: ${res.status}`);
+  return res.json();
+}
+
 export async function fetchStats() {
   const res = await fetch(`${BASE}/api/stats`);
   if (!res.ok) throw new Error(`Failed to fetch stats: ${res.status}`);
   return res.json();
 }
+
+export async function fetchCategories() {
+  const res = await fetch(`${BASE}/api/categories`);
+  if (!res.ok) throw new Error(`Failed to fetch categories: ${res.status}`);
+  return res.json();
+}
+
+export async function fetchCluster(id) {
+  const res = await fetch(`${BASE}/api/clusters/${id}`);
+  if (!res.ok) throw new Error(`Failed to fetch cluster: ${res.status}`);
+  return res.json();
+}
@@ -1,4 +1,8 @@
+import { useCategories } from '../hooks/useCategories';
+
 export default function AboutModal({ onClose }) {
+  const categories = useCategories();
+
   const sectionTitle = {
     fontFamily: "'Playfair Display', Georgia, serif",
     fontSize: 18, fontWeight: 700, color: "#1a1a2e",
@@ -86,16 +90,8 @@ export default function AboutModal({ onClose }) {
         {/* Categories */}
         <h3 style={sectionTitle}>Categories</h3>
         <div style={{ marginBottom: 20 }}>
-          {[
-            { icon: "\uD83C\uDF10", label: "Web Apps", desc: "web frameworks, sites, frontends" },
-            { icon: "\uD83D\uDCF1", label: "Mobile", desc: "iOS, Android, React Native" },
-            { icon: "\uD83E\uDDE0", label: "AI / ML", desc: "machine learning, NLP, data science" },
-            { icon: "\uD83D\uDD27", label: "Dev Tools", desc: "CLIs, libraries, build tools" },
-            { icon: "\uD83D\uDCCA", label: "Data", desc: "databases, pipelines, visualization" },
-            { icon: "\uD83C\uDFAE", label: "Games", desc: "game engines, simulations, fun projects" },
-            { icon: "\uD83D\uDC80", label: "Other", desc: "everything else" },
-          ].map(c => (
-            <span key={c.label} style={categoryChip}>{c.icon} {c.label} — {c.desc}</span>
+          {categories.filter(c => c.id !== "all" && !c.parent).map(c => (
+            <span key={c.id} style={categoryChip}>{c.icon} {c.label}{c.description && ` — ${c.description}`}</span>
           ))}
         </div>
 
@@ -1,4 +1,4 @@
-import { CATEGORIES } from '../utils/helpers';
+import { useCategories } from '../hooks/useCategories';
 
 export default function Controls({
   searchText, onSearchChange,
@@ -7,6 +7,11 @@ export default function Controls({
   onRefresh, loading, refreshing,
   viewMode, onViewModeChange,
 }) {
+  const categories = useCategories();
+  // Subcategories show in a second row once their parent, or one of them, is picked.
+  const selected = categories.find(c => c.id === selectedCategory);
+  const area = selected && (selected.parent || selected.id);
+  const subcategories = categories.filter(c => c.parent && c.parent === area);
   return (
     <div style={{
       position: "relative", zIndex: 1,
@@ -39,9 +44,10 @@ export default function Controls({
 
       {/* Category pills */}
       <div style={{
-        display: "flex", flexWrap: "wrap", gap: 8, marginBottom: 16,
+        display: "flex", flexWrap: "wrap", gap: 8,
+        marginBottom: subcategories.length > 0 ? 10 : 16,
       }}>
-        {CATEGORIES.map(cat => (
+        {categories.filter(cat => !cat.parent).map(cat => (
           <button
             key={cat.id}
             onClick={() => onCategoryChange(cat.id)}
@@ -49,9 +55,9 @@ export default function Controls({
               display: "flex", alignItems: "center", gap: 5,
               padding: "6px 14px",
               borderRadius: 8,
-              border: selectedCategory === cat.id ? "1px solid #6366f1" : "1px solid #ddd8d0",
-              background: selectedCategory === cat.id ? "#eef2ff" : "#ffffff",
-              color: selectedCategory === cat.id ? "#4f46e5" : "#6a6a88",
+              border: area === cat.id ? "1px solid #6366f1" : "1px solid #ddd8d0",
+              background: area === cat.id ? "#eef2ff" : "#ffffff",
+              color: area === cat.id ? "#4f46e5" : "#6a6a88",
               fontSize: 13,
               fontFamily: "'IBM Plex Sans', sans-serif",
               fontWeight: 500,
@@ -65,6 +71,36 @@ export default function Controls({
         ))}
       </div>
 
+      {/* Subcategory pills; picking the selected one again goes back to its parent */}
+      {subcategories.length > 0 && (
+        <div style={{
+          display: "flex", flexWrap: "wrap", gap: 6, marginBottom: 16,
+        }}>
+          {subcategories.map(cat => (
+            <button
+              key={cat.id}
+              onClick={() => onCategoryChange(selectedCategory === cat.id ? area : cat.id)}
+              style={{
+                display: "flex", alignItems: "center", gap: 4,
+                padding: "4px 11px",
+                borderRadius: 7,
+                border: selectedCategory === cat.id ? "1px solid #6366f1" : "1px solid #e6e2da",
+                background: selectedCategory === cat.id ? "#eef2ff" : "#faf9f6",
+                color: selectedCategory === cat.id ? "#4f46e5" : "#7a7a94",
+                fontSize: 12,
+                fontFamily: "'IBM Plex Sans', sans-serif",
+                fontWeight: 500,
+                cursor: "pointer",
+                transition: "all 0.2s",
+              }}
+            >
+              <span style={{ fontSize: 13 }}>{cat.icon}</span>
+              {cat.label}
+            </button>
+          ))}
+        </div>
+      )}
+
       {/* Sort + refresh */}
       <div style={{ display: "flex", justifyContent: "space-between", alignItems: "center", marginBottom: 20 }}>
         <div style={{ display: "flex", gap: 6 }}>
@@ -73,6 +109,9 @@ export default function Controls({
             { id: "score", label: "Best Ideas" },
             { id: "stars", label: "Most Stars" },
             { id: "oldest", label: "Most Stale" },
+            { id: "died_suddenly", label: "Died Suddenly" },
+            { id: "star_velocity", label: "Fastest Rise" },
+            { id: "revivability", label: "Easiest Revival" },
           ].map(s => (
             <button
               key={s.id}
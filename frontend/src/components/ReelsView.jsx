@@ -1,5 +1,6 @@
 import { useEffect, useRef, useCallback } from 'react';
-import { CATEGORIES, timeAgo, scoreColor, scoreGlowColor } from '../utils/helpers';
+import { findCategory, timeAgo, scoreColor, scoreGlowColor, repoHost } from '../utils/helpers';
+import { useCategories } from '../hooks/useCategories';
 
 export default function ReelsView({ repos, loadMore, hasMore, loadingMore, onClose }) {
   const containerRef = useRef(null);
@@ -43,7 +44,7 @@ export default function ReelsView({ repos, loadMore, hasMore, loadingMore, onClo
 
       <div className="reels-container" ref={containerRef}>
         {repos.map((repo, i) => (
-          <ReelSlide key={repo.id} repo={repo} index={i} />
+          <ReelSlide key={`${repo.source}-${repo.id}`} repo={repo} index={i} />
         ))}
 
         {loadingMore && (
@@ -63,7 +64,8 @@ export default function ReelsView({ repos, loadMore, hasMore, loadingMore, onClo
 }
 
 function ReelSlide({ repo, index }) {
-  const cat = CATEGORIES.find(c => c.id === repo.category) || CATEGORIES[7];
+  const categories = useCategories();
+  const cat = findCategory(categories, repo.category);
   const score = repo.idea_score;
   const color = scoreColor(score);
   const glowColor = scoreGlowColor(score);
@@ -202,7 +204,7 @@ function ReelSlide({ repo, index }) {
           </div>
         )}
 
-        {/* GitHub button */}
+        {/* Source repo button */}
         <a
           href={repo.html_url}
           target="_blank"
@@ -221,7 +223,7 @@ function ReelSlide({ repo, index }) {
           onMouseEnter={e => { e.currentTarget.style.opacity = '0.9'; e.currentTarget.style.transform = 'scale(1.02)'; }}
           onMouseLeave={e => { e.currentTarget.style.opacity = '1'; e.currentTarget.style.transform = 'scale(1)'; }}
         >
-          View on GitHub {"\u2192"}
+          View on {repoHost(repo.html_url)} {"\u2192"}
         </a>
       </div>
 
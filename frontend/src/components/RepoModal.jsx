@@ -1,10 +1,73 @@
-import { CATEGORIES, timeAgo } from '../utils/helpers';
+import { useEffect, useState } from 'react';
+import { findCategory, timeAgo } from '../utils/helpers';
+import { fetchCluster, fetchReadme, fetchRepo } from '../api';
+import { useCategories } from '../hooks/useCategories';
+
+// Labels for score breakdown components; license_<class> is handled apart.
+const SCORE_LABELS = {
+  stars: "Stars",
+  forks: "Forks",
+  star_velocity: "Stars per month",
+  lifespan: "Lifespan",
+  recent_death: "Died recently",
+  description: "Has a description",
+  description_length: "Description length",
+  topics: "Has topics",
+  readme: "Has a README",
+};
+
+function scoreLabel(name) {
+  if (name.startsWith("license_")) return `License: ${name.slice(8)}`;
+  return SCORE_LABELS[name] || name.replace(/_/g, " ");
+}
 
 export default function RepoModal({ repo, onClose }) {
+  const [readme, setReadme] = useState(null);
+  const [readmeState, setReadmeState] = useState("idle");
+  const [breakdown, setBreakdown] = useState(null);
+  const [cluster, setCluster] = useState(null);
+  const categories = useCategories();
+
+  useEffect(() => {
+    setReadme(null);
+    setReadmeState("idle");
+    setBreakdown(null);
+    setCluster(null);
+    if (!repo) return;
+    let cancelled = false;
+    fetchRepo(repo.source, repo.id)
+      .then(r => { if (!cancelled) setBreakdown(r.score_breakdown || null); })
+      .catch(() => {});
+    if (repo.cluster_id) {
+      fetchCluster(repo.cluster_id)
+        .then(c => { if (!cancelled) setCluster(c); })
+        .catch(() => {});
+    }
+    return () => { cancelled = true; };
+  }, [repo?.source, repo?.id, repo?.cluster_id]);
+
   if (!repo) return null;
-  const cat = CATEGORIES.find(c => c.id === repo.category) || CATEGORIES[7];
+  const cat = findCategory(categories, repo.category);
   const score = repo.idea_score;
 
+  const toggleReadme = async () => {
+    if (readmeState === "open") {
+      setReadmeState("idle");
+      return;
+    }
+    if (readme) {
+      setReadmeState("open");
+      return;
+    }
+    setReadmeState("loading");
+    try {
+      setReadme(await fetchReadme(repo.source, repo.id));
+      setReadmeState("open");
+    } catch {
+      setReadmeState("error");
+    }
+  };
+
   return (
     <div
       onClick={onClose}
@@ -28,7 +91,17 @@ export default function RepoModal({ repo, onClose }) {
         }}
       >
         <div style={{ display: "flex", justifyContent: "space-between", alignItems: "center", marginBottom: 20 }}>
-          <span style={{ fontSize: 32 }}>{cat.icon}</span>
+          <span style={{ fontSize: 32 }}>
+            {cat.icon}
+            {(repo.categories || []).slice(1).map(l => {
+              const other = categories.find(c => c.id === l.id);
+              return other && (
+                <span key={l.id} title={other.label} style={{ fontSize: 20, marginLeft: 6, opacity: 0.7 }}>
+                  {other.icon}
+                </span>
+              );
+            })}
+          </span>
           <button onClick={onClose} style={{
             background: "none", border: "1px solid #d8d4cc", borderRadius: 8,
             color: "#8888a0", fontSize: 14, cursor: "pointer", padding: "4px 12px",
@@ -74,6 +147,62 @@ export default function RepoModal({ repo, onClose }) {
           ))}
         </div>
 
+        {breakdown && (
+          <div style={{
+            background: "#f8f6f2", borderRadius: 10, padding: "10px 14px",
+            border: "1px solid #ece9e4", marginBottom: 20,
+            fontFamily: "'IBM Plex Mono', monospace", fontSize: 12, color: "#6a6a88",
+          }}>
+            <div style={{ fontSize: 11, color: "#8888a0", marginBottom: 6 }}>
+              {"\u25C6"} Why {score}?
+            </div>
+            {breakdown.map(c => (
+              <div key={c.name} style={{ display: "flex", justifyContent: "space-between", padding: "2px 0" }}>
+                <span>{scoreLabel(c.name)}</span>
+                <span style={{ color: c.points < 0 ? "#b45309" : "#1a1a2e" }}>
+                  {c.points >= 0 ? "+" : ""}{c.points.toFixed(1)}
+                </span>
+              </div>
+            ))}
+          </div>
+        )}
+
+        {repo.revivability_score > 0 && (
+          <div style={{
+            fontSize: 12, color: "#6a6a88", marginBottom: 16,
+            fontFamily: "'IBM Plex Mono', monospace",
+          }}>
+            {"\u2692"} Revivability {repo.revivability_score}/100
+            {repo.health ? [
+              repo.health.has_tests ? "tests" : "no tests",
+              repo.health.has_ci ? "CI" : "no CI",
+              repo.health.build_status !== "unknown" && `build ${repo.health.build_status}`,
+            ].filter(Boolean).map(s => ` \u00B7 ${s}`).join("") : ""}
+          </div>
+        )}
+
+        {repo.license_class && (
+          <div style={{
+            fontSize: 12, color: "#6a6a88", marginBottom: 16,
+            fontFamily: "'IBM Plex Mono', monospace",
+          }}>
+            {"\u00A7"} {repo.license_class === "none"
+              ? "No license \u2014 all rights reserved"
+              : `${repo.license && repo.license !== "NOASSERTION" ? repo.license : "Unknown license"} (${repo.license_class})`}
+          </div>
+        )}
+
+        {repo.owner && repo.owner.type !== "deleted" && (
+          <div style={{
+            fontSize: 12, color: "#6a6a88", marginBottom: 16,
+            fontFamily: "'IBM Plex Mono', monospace",
+          }}>
+            {"\u263A"} {repo.owner.login}{repo.owner.type === "org" ? " (org)" : ""}
+            {repo.owner.last_active_at ? ` \u00B7 last active ${timeAgo(repo.owner.last_active_at)}` : ""}
+            {repo.owner.stale_repos > 1 ? ` \u00B7 ${repo.owner.stale_repos - 1} other stale repos` : ""}
+          </div>
+        )}
+
         {(repo.topics || []).length > 0 && (
           <div style={{ display: "flex", flexWrap: "wrap", gap: 6, marginBottom: 20 }}>
             {repo.topics.map(t => (
@@ -86,6 +215,49 @@ export default function RepoModal({ repo, onClose }) {
           </div>
         )}
 
+        {(repo.stack || []).length > 0 && (
+          <div style={{ display: "flex", flexWrap: "wrap", alignItems: "center", gap: 6, marginBottom: 20 }}>
+            <span style={{ fontSize: 11, color: "#8888a0", fontFamily: "'IBM Plex Mono', monospace", marginRight: 2 }}>
+              Built with
+            </span>
+            {repo.stack.map(s => (
+              <span key={s} style={{
+                fontSize: 12, color: "#3a5a40", background: "#e6efe4",
+                borderRadius: 6, padding: "3px 10px",
+                fontFamily: "'IBM Plex Mono', monospace",
+              }}>{s}</span>
+            ))}
+          </div>
+        )}
+
+        {cluster && cluster.repos.length > 1 && (
+          <div style={{
+            background: "#f8f6f2", borderRadius: 10, padding: "10px 14px",
+            border: "1px solid #ece9e4", marginBottom: 20,
+            fontFamily: "'IBM Plex Mono', monospace", fontSize: 12, color: "#6a6a88",
+          }}>
+            <div style={{ fontSize: 11, color: "#8888a0", marginBottom: 6 }}>
+              {"\u2261"} {cluster.repos.length - 1} other {cluster.repos.length === 2 ? "attempt" : "attempts"} at this idea
+              {cluster.terms.length > 0 ? ` \u00B7 ${cluster.terms.join(" ")}` : ""}
+            </div>
+            {cluster.repos
+              .filter(r => r.source !== repo.source || r.id !== repo.id)
+              .map(r => (
+                <div key={`${r.source}:${r.id}`} style={{ display: "flex", justifyContent: "space-between", gap: 12, padding: "2px 0" }}>
+                  <a
+                    href={r.html_url}
+                    target="_blank"
+                    rel="noopener noreferrer"
+                    style={{ color: "#4f46e5", textDecoration: "none", overflow: "hidden", textOverflow: "ellipsis", whiteSpace: "nowrap" }}
+                  >
+                    {r.full_name}
+                  </a>
+                  <span style={{ flexShrink: 0 }}>{"\u2605"} {r.stargazers_count}</span>
+                </div>
+              ))}
+          </div>
+        )}
+
         <div style={{ display: "flex", gap: 10 }}>
           <a
             href={repo.html_url}
@@ -104,25 +276,43 @@ export default function RepoModal({ repo, onClose }) {
           >
             View Source Repo {"\u2192"}
           </a>
-          <a
-            href={`https://github.com/${repo.full_name}/blob/HEAD/README.md`}
-            target="_blank"
-            rel="noopener noreferrer"
+          <button
+            onClick={toggleReadme}
             style={{
               display: "flex", alignItems: "center", justifyContent: "center",
               padding: "12px 16px", borderRadius: 10,
               background: "transparent", border: "1px solid #d8d4cc",
-              color: "#5a5a78", textDecoration: "none", fontSize: 14,
+              color: "#5a5a78", fontSize: 14, cursor: "pointer",
               fontFamily: "'IBM Plex Sans', sans-serif", fontWeight: 500,
               transition: "border-color 0.2s",
             }}
             onMouseEnter={e => e.currentTarget.style.borderColor = "#6366f1"}
             onMouseLeave={e => e.currentTarget.style.borderColor = "#d8d4cc"}
           >
-            README
-          </a>
+            {readmeState === "loading" ? "Loading\u2026" : readmeState === "open" ? "Hide README" : "README"}
+          </button>
         </div>
 
+        {readmeState === "error" && (
+          <p style={{ fontSize: 13, color: "#8888a0", margin: "12px 0 0", fontFamily: "'IBM Plex Sans', sans-serif" }}>
+            README not available yet.
+          </p>
+        )}
+
+        {readmeState === "open" && readme && (
+          <div
+            className="readme-body"
+            style={{
+              marginTop: 16, padding: "14px 16px", borderRadius: 10,
+              background: "#f8f6f2", border: "1px solid #ece9e4",
+              fontFamily: "'IBM Plex Sans', sans-serif", fontSize: 14,
+              color: "#3a3a58", lineHeight: 1.6, overflowX: "auto",
+            }}
+            // The backend renders READMEs to sanitized HTML.
+            dangerouslySetInnerHTML={{ __html: readme.html }}
+          />
+        )}
+
         <div style={{
           marginTop: 16, padding: "12px 14px", borderRadius: 10,
           background: "#fef9ee", border: "1px dashed #e8dcc8",
@@ -1,10 +1,12 @@
 import { useRef } from 'react';
-import { CATEGORIES, timeAgo, scoreGlowColor } from '../utils/helpers';
+import { findCategory, timeAgo, scoreGlowColor } from '../utils/helpers';
 import ScoreBadge from './ScoreBadge';
+import { useCategories } from '../hooks/useCategories';
 
 export default function Tombstone({ repo, index, animate = true, onClick }) {
   const shouldAnimate = useRef(animate).current;
-  const cat = CATEGORIES.find(c => c.id === repo.category) || CATEGORIES[7];
+  const categories = useCategories();
+  const cat = findCategory(categories, repo.category);
   const score = repo.idea_score;
   const pushed = timeAgo(repo.pushed_at);
   const lang = repo.language;
@@ -86,6 +88,9 @@ export default function Tombstone({ repo, index, animate = true, onClick }) {
           <span>{"\u2605"} {repo.stargazers_count}</span>
           <span>{"\u2442"} {repo.forks_count}</span>
           {lang && <span style={{ color: "#6366f1" }}>{lang}</span>}
+          {repo.cluster_size > 1 && (
+            <span title="Near-duplicate attempts at the same idea">{"\u2261"} +{repo.cluster_size - 1}</span>
+          )}
         </div>
         <span style={{ fontSize: 11, color: "#a0a0b4", fontFamily: "'IBM Plex Mono', monospace" }}>
           {"\u2620"} {pushed}
@@ -0,0 +1,28 @@
+import { useState, useEffect } from 'react';
+import { fetchCategories } from '../api';
+import { CATEGORIES, ALL_CATEGORY } from '../utils/helpers';
+
+// Shared by every component, so the taxonomy is fetched once per page load.
+let loaded = null;
+let pending = null;
+
+// useCategories returns the taxonomy, led by the "all" pseudo-category, as
+// served by the API. The built-in CATEGORIES stand in until it has loaded.
+export function useCategories() {
+  const [categories, setCategories] = useState(loaded || CATEGORIES);
+
+  useEffect(() => {
+    if (loaded) return;
+    let cancelled = false;
+    pending = pending || fetchCategories().then(list => {
+      loaded = [ALL_CATEGORY, ...list];
+      return loaded;
+    });
+    pending
+      .then(c => { if (!cancelled) setCategories(c); })
+      .catch(() => { pending = null; });
+    return () => { cancelled = true; };
+  }, []);
+
+  return categories;
+}
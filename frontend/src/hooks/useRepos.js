@@ -1,7 +1,9 @@
 import { useState, useEffect, useCallback, useRef } from 'react';
 import { fetchRepos, refreshRepos } from '../api';
 
-export function useRepos({ category, sort, search, perPage = 30 } = {}) {
+// useRepos pages through the repo listing. With collapseClusters, each idea
+// cluster of near-duplicates shows as its best repo.
+export function useRepos({ category, sort, search, perPage = 30, collapseClusters = true } = {}) {
   const [repos, setRepos] = useState([]);
   const [total, setTotal] = useState(0);
   const [page, setPage] = useState(1);
@@ -32,7 +34,7 @@ export function useRepos({ category, sort, search, perPage = 30 } = {}) {
     setError(null);
 
     try {
-      const data = await fetchRepos({ category, sort, search, page, perPage });
+      const data = await fetchRepos({ category, sort, search, page, perPage, collapseClusters });
       if (isFirstPage) {
         setRepos(data.repos);
         setAppendedFrom(null);
@@ -46,7 +48,7 @@ export function useRepos({ category, sort, search, perPage = 30 } = {}) {
     }
     setLoading(false);
     setLoadingMore(false);
-  }, [category, sort, search, page, perPage]);
+  }, [category, sort, search, page, perPage, collapseClusters]);
 
   useEffect(() => {
     load();
@@ -69,7 +71,7 @@ export function useRepos({ category, sort, search, perPage = 30 } = {}) {
       const poll = async () => {
         attempts++;
         try {
-          const data = await fetchRepos({ category, sort, search, page: 1, perPage });
+          const data = await fetchRepos({ category, sort, search, page: 1, perPage, collapseClusters });
           if (data.total !== prevTotal || attempts >= 5) {
             setPage(1);
             setRepos(data.repos);
@@ -87,7 +89,7 @@ export function useRepos({ category, sort, search, perPage = 30 } = {}) {
       setError(err.message);
       setRefreshing(false);
     }
-  }, [total, category, sort, search, perPage]);
+  }, [total, category, sort, search, perPage, collapseClusters]);
 
   return { repos, total, loading, loadingMore, refreshing, error, refresh, reload: load, hasMore, loadMore, appendedFrom };
 }
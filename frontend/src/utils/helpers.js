@@ -7,6 +7,14 @@ export function timeAgo(dateStr) {
   return "recently";
 }
 
+export function repoHost(url) {
+  try {
+    return new URL(url).host.replace(/^www\./, "");
+  } catch {
+    return "source";
+  }
+}
+
 export function scoreColor(score) {
   if (score >= 70) return "#059669";
   if (score >= 40) return "#d97706";
@@ -19,13 +27,41 @@ export function scoreGlowColor(score) {
   return "#818cf8";
 }
 
+// CATEGORIES is shown until the taxonomy has loaded from the API (see
+// useCategories), and if it can't be.
 export const CATEGORIES = [
   { id: "all", label: "All Ideas", icon: "\u26B0\uFE0F" },
-  { id: "web", label: "Web Apps", icon: "\uD83C\uDF10" },
-  { id: "mobile", label: "Mobile", icon: "\uD83D\uDCF1" },
-  { id: "ai", label: "AI / ML", icon: "\uD83E\uDDE0" },
-  { id: "dev-tools", label: "Dev Tools", icon: "\uD83D\uDD27" },
-  { id: "data", label: "Data", icon: "\uD83D\uDCCA" },
-  { id: "game", label: "Games", icon: "\uD83C\uDFAE" },
-  { id: "other", label: "Other", icon: "\uD83D\uDC80" },
+  { id: "mobile", label: "Mobile", icon: "\uD83D\uDCF1", description: "iOS, Android, React Native" },
+  { id: "mobile/ios", parent: "mobile", label: "iOS", icon: "\uD83C\uDF4F", description: "iPhone and iPad apps" },
+  { id: "mobile/android", parent: "mobile", label: "Android", icon: "\uD83E\uDD16", description: "Android apps" },
+  { id: "game", label: "Games", icon: "\uD83C\uDFAE", description: "game engines, simulations, fun projects" },
+  { id: "ai", label: "AI / ML", icon: "\uD83E\uDDE0", description: "machine learning, NLP, data science" },
+  { id: "ai/nlp", parent: "ai", label: "Language", icon: "\uD83D\uDCAC", description: "NLP, chatbots, LLM apps" },
+  { id: "ai/vision", parent: "ai", label: "Vision", icon: "\uD83D\uDC41\uFE0F", description: "computer vision, image recognition, OCR" },
+  { id: "security", label: "Security", icon: "\uD83D\uDEE1\uFE0F", description: "pentesting, exploits, cryptography, privacy" },
+  { id: "blockchain", label: "Blockchain", icon: "\u26D3\uFE0F", description: "cryptocurrencies, smart contracts, web3" },
+  { id: "embedded", label: "Embedded / IoT", icon: "\uD83D\uDD0C", description: "microcontrollers, firmware, smart home" },
+  { id: "education", label: "Education", icon: "\uD83C\uDF93", description: "tutorials, courses, exercises" },
+  { id: "productivity", label: "Productivity", icon: "\u2705", description: "todo lists, notes, calendars, time tracking" },
+  { id: "web", label: "Web Apps", icon: "\uD83C\uDF10", description: "web frameworks, sites, frontends" },
+  { id: "web/dashboard", parent: "web", label: "Dashboards", icon: "\uD83D\uDCC8", description: "admin panels and dashboards" },
+  { id: "web/api", parent: "web", label: "APIs & Backends", icon: "\u2699\uFE0F", description: "REST and GraphQL services" },
+  { id: "web/site", parent: "web", label: "Sites & Blogs", icon: "\uD83D\uDCDD", description: "static sites, blogs, portfolios" },
+  { id: "data", label: "Data", icon: "\uD83D\uDCCA", description: "databases, pipelines, visualization" },
+  { id: "data/scraping", parent: "data", label: "Scraping", icon: "\uD83D\uDD77\uFE0F", description: "scrapers and crawlers" },
+  { id: "data/visualization", parent: "data", label: "Visualization", icon: "\uD83D\uDCC9", description: "charts, plots and graphs" },
+  { id: "dev-tools", label: "Dev Tools", icon: "\uD83D\uDD27", description: "CLIs, libraries, build tools" },
+  { id: "dev-tools/cli", parent: "dev-tools", label: "CLIs", icon: "\u2328\uFE0F", description: "command-line and terminal tools" },
+  { id: "dev-tools/editor", parent: "dev-tools", label: "Editor Plugins", icon: "\uD83E\uDDE9", description: "extensions for VS Code, Vim, Emacs and other editors" },
+  { id: "other", label: "Other", icon: "\uD83D\uDC80", description: "everything else" },
 ];
+
+export const ALL_CATEGORY = CATEGORIES[0];
+
+// findCategory returns the category with the given id, falling back to
+// "other" for ids the taxonomy no longer has.
+export function findCategory(categories, id) {
+  return categories.find(c => c.id === id)
+    || categories.find(c => c.id === "other")
+    || CATEGORIES[CATEGORIES.length - 1];
+}